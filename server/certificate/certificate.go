@@ -0,0 +1,84 @@
+// Package certificate renders training completion certificates.
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+)
+
+// Data is the data for a certificate. The fields are available to the
+// template text.
+type Data struct {
+	Name        string
+	Class       string
+	ClassNumber int
+	Council     string
+	Date        string
+	Year        int
+}
+
+// CheckTemplate returns an error if the template text is not valid.
+func CheckTemplate(t *model.CertificateTemplate) error {
+	var buf bytes.Buffer
+	return executeText(&buf, t, &Data{})
+}
+
+func executeText(buf *bytes.Buffer, t *model.CertificateTemplate, data *Data) error {
+	tmpl, err := template.New(t.Name).Parse(t.Text)
+	if err != nil {
+		return fmt.Errorf("certificate template %q: %v", t.Name, err)
+	}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return fmt.Errorf("certificate template %q: %v", t.Name, err)
+	}
+	return nil
+}
+
+// Add adds a certificate page to the document.
+func Add(doc *pdf.Document, t *model.CertificateTemplate, data *Data) error {
+	var buf bytes.Buffer
+	if err := executeText(&buf, t, data); err != nil {
+		return err
+	}
+
+	// Landscape letter.
+	const (
+		width  = pdf.LetterHeight
+		height = pdf.LetterWidth
+		margin = 0.5 * pdf.Inch
+		text   = width - 3*margin
+	)
+	p := doc.AddPage(width, height)
+	p.Rect(margin, margin, width-2*margin, height-2*margin, 3)
+	p.Rect(margin+6, margin+6, width-2*margin-12, height-2*margin-12, 0.75)
+
+	x := width / 2
+	y := height - 2*pdf.Inch
+	p.FitText(x, y, text, pdf.TimesBold, 40, pdf.Center, t.Title)
+	y -= 0.75 * pdf.Inch
+	p.Text(x, y, pdf.TimesRoman, 16, pdf.Center, "This certifies that")
+	y -= 0.7 * pdf.Inch
+	p.FitText(x, y, text, pdf.TimesBold, 32, pdf.Center, data.Name)
+	p.Line(x-3*pdf.Inch, y-8, x+3*pdf.Inch, y-8, 0.5)
+	y -= 0.6 * pdf.Inch
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		p.FitText(x, y, text, pdf.TimesRoman, 18, pdf.Center, strings.TrimSpace(line))
+		y -= 26
+	}
+	p.Text(x, y-4, pdf.TimesRoman, 14, pdf.Center, data.Date)
+
+	// Signature lines evenly spaced across the bottom of the page.
+	n := len(t.Signatures)
+	for i, caption := range t.Signatures {
+		sx := margin + (width-2*margin)*float64(2*i+1)/float64(2*n)
+		sy := margin + 0.9*pdf.Inch
+		p.Line(sx-1.4*pdf.Inch, sy, sx+1.4*pdf.Inch, sy, 0.5)
+		p.FitText(sx, sy-14, 2.8*pdf.Inch, pdf.TimesRoman, 12, pdf.Center, caption)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package conflict finds scheduling conflicts in the conference timetable.
+package conflict
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seaptc/server/model"
+)
+
+// Kind is the kind of a conflict.
+type Kind string
+
+const (
+	// Room is a location used by more than one class in a session.
+	Room Kind = "room"
+
+	// Instructor is an instructor email listed on more than one class in a
+	// session.
+	Instructor Kind = "instructor"
+
+	// Participant is a participant teaching a class in a session where
+	// they are also registered for a class.
+	Participant Kind = "participant"
+)
+
+// Conflict is a pair of classes that cannot both use a resource in a
+// session.
+type Conflict struct {
+	Kind Kind
+
+	// Zero based index of the first session where the classes overlap.
+	Session int
+
+	// Resource is the location, the instructor email or the participant
+	// name.
+	Resource string
+
+	// ParticipantID is set for participant conflicts.
+	ParticipantID string
+
+	// Classes are the conflicting classes in increasing order by number.
+	Classes [2]*model.Class
+}
+
+func (c *Conflict) String() string {
+	switch c.Kind {
+	case Room:
+		return fmt.Sprintf("Room %s used by class %d and %d", c.Resource, c.Classes[0].Number, c.Classes[1].Number)
+	case Instructor:
+		return fmt.Sprintf("Instructor %s teaching class %d and %d", c.Resource, c.Classes[0].Number, c.Classes[1].Number)
+	default:
+		return fmt.Sprintf("%s registered for class %d and teaching class %d", c.Resource, c.Classes[0].Number, c.Classes[1].Number)
+	}
+}
+
+// finder collects conflicts, reporting each pair of classes once even when
+// the classes overlap in more than one session.
+type finder struct {
+	conflicts []*Conflict
+	seen      map[string]bool
+}
+
+func (f *finder) add(kind Kind, session int, resource string, id string, a, b *model.Class) {
+	if a.Number > b.Number && kind != Participant {
+		a, b = b, a
+	}
+	key := fmt.Sprintf("%s/%s/%s/%d/%d", kind, strings.ToLower(resource), id, a.Number, b.Number)
+	if f.seen[key] {
+		return
+	}
+	f.seen[key] = true
+	f.conflicts = append(f.conflicts, &Conflict{
+		Kind:          kind,
+		Session:       session,
+		Resource:      resource,
+		ParticipantID: id,
+		Classes:       [2]*model.Class{a, b},
+	})
+}
+
+// Classes returns the room and instructor conflicts between classes.
+func Classes(ci *model.ClassInfo) []*Conflict {
+	f := &finder{seen: make(map[string]bool)}
+	for session, scs := range ci.Sessions() {
+		rooms := make(map[string]*model.Class)
+		instructors := make(map[string]*model.Class)
+		for _, sc := range scs {
+			if sc.Location != "" {
+				room := strings.ToLower(sc.Location)
+				if c, ok := rooms[room]; ok {
+					f.add(Room, session, sc.Location, "", c, sc.Class)
+				} else {
+					rooms[room] = sc.Class
+				}
+			}
+			for _, email := range model.SplitComma(sc.InstructorEmails) {
+				key := strings.ToLower(strings.TrimSpace(email))
+				if c, ok := instructors[key]; ok {
+					f.add(Instructor, session, email, "", c, sc.Class)
+				} else {
+					instructors[key] = sc.Class
+				}
+			}
+		}
+	}
+	sortConflicts(f.conflicts)
+	return f.conflicts
+}
+
+// Participants returns the conflicts between the classes a participant is
+// registered for and the classes the participant is teaching. For
+// participant conflicts, the registered class is first.
+func Participants(ci *model.ClassInfo, participants []*model.Participant) []*Conflict {
+	f := &finder{seen: make(map[string]bool)}
+	for _, p := range participants {
+		if len(p.InstructorClasses) == 0 {
+			continue
+		}
+		registered := make([]*model.Class, model.NumSession)
+		for _, n := range p.Classes {
+			c := ci.LookupNumber(n)
+			if c == nil {
+				continue
+			}
+			start, end := c.StartEnd()
+			for i := start; i <= end && i < model.NumSession; i++ {
+				registered[i] = c
+			}
+		}
+		for _, ic := range p.InstructorClasses {
+			if ic.Session < 0 || ic.Session >= model.NumSession {
+				continue
+			}
+			rc := registered[ic.Session]
+			c := ci.LookupNumber(ic.Class)
+			if rc == nil || c == nil || rc.Number == c.Number {
+				continue
+			}
+			f.add(Participant, ic.Session, p.Name(), p.ID, rc, c)
+		}
+	}
+	sortConflicts(f.conflicts)
+	return f.conflicts
+}
+
+func sortConflicts(conflicts []*Conflict) {
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		a, b := conflicts[i], conflicts[j]
+		switch {
+		case a.Session != b.Session:
+			return a.Session < b.Session
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		default:
+			return strings.ToLower(a.Resource) < strings.ToLower(b.Resource)
+		}
+	})
+}
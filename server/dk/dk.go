@@ -58,11 +58,6 @@ var setters = []struct {
 	{"How many years have you been in scouting?", func(p *participant, s string) { p.ScoutingYears = s }},
 	{"Print QR code on PTC name badge?", func(p *participant, s string) { p.ShowQRCode = s == "Yes" }},
 
-	// addDietaryRestriction assumes that Vegan is parsed first.
-	{"Do you have any meal requirements?:Vegan", addDietaryRestriction},
-	{"Do you have any meal requirements?:Vegetarian", addDietaryRestriction},
-	{"Do you have any meal requirements?:Gluten Free", addDietaryRestriction},
-
 	// Downstream code assumes that the other option is parsed last.
 	{"How did you hear about the PTC?:Roundtable/District", addMarketing},
 	{"How did you hear about the PTC?:eTotem", addMarketing},
@@ -75,18 +70,15 @@ var setters = []struct {
 	{"Which organization are you representing on the midway?", func(r *participant, s string) { r.midwayDescription = s }},
 }
 
-func addDietaryRestriction(p *participant, s string) {
-	if s == "" {
+func addAllergies(p *participant, s string) {
+	switch strings.ToLower(s) {
+	case "", "none", "n/a", "na", "no":
 		return
 	}
-	if p.DietaryRestrictions == "Vegan" && s == "Vegetarian" {
-		// Vegan is more restrictive than vegetarian
-		return
-	}
-	if p.DietaryRestrictions == "" {
-		p.DietaryRestrictions = s
+	if p.Allergies == "" {
+		p.Allergies = s
 	} else {
-		p.DietaryRestrictions = p.DietaryRestrictions + "; " + s
+		p.Allergies = p.Allergies + "; " + s
 	}
 }
 
@@ -101,7 +93,10 @@ func addMarketing(p *participant, s string) {
 	}
 }
 
-func ParseCSV(rd io.Reader) ([]*model.Participant, error) {
+// ParseCSV parses a Doubleknot registration export in CSV format. The
+// dietary tag and allergy columns are read from conf. The default columns
+// are used if conf is nil.
+func ParseCSV(rd io.Reader, conf *model.Conference) ([]*model.Participant, error) {
 
 	/*
 		// Skip BOM
@@ -118,6 +113,29 @@ func ParseCSV(rd io.Reader) ([]*model.Participant, error) {
 		return nil, fmt.Errorf("dk: error reading header: %v", err)
 	}
 
+	rows := [][]string{header}
+	for {
+		row, err := csvr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return ParseRows(rows, conf)
+}
+
+// ParseRows parses the rows of a Doubleknot registration export. The first
+// row is the header. Missing cells at the end of a row are treated as blank
+// as is the case for exports saved as .xlsx. The dietary tag and allergy
+// columns are read from conf. The default columns are used if conf is nil.
+func ParseRows(rows [][]string, conf *model.Conference) ([]*model.Participant, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("dk: error reading header: missing header row")
+	}
+	header := rows[0]
+
 	columnIndex := map[string]int{}
 	for j, name := range header {
 		columnIndex[name] = j
@@ -127,26 +145,45 @@ func ParseCSV(rd io.Reader) ([]*model.Participant, error) {
 			return nil, fmt.Errorf("could not find column %q in export file", s.name)
 		}
 	}
+	if conf == nil {
+		conf = &model.Conference{}
+	}
+	dietaryTags := conf.DietaryTagList()
+	for _, t := range dietaryTags {
+		for _, name := range t.Columns {
+			if _, ok := columnIndex[name]; !ok {
+				return nil, fmt.Errorf("could not find dietary column %q in export file", name)
+			}
+		}
+	}
+	for _, name := range conf.AllergyColumns {
+		if _, ok := columnIndex[name]; !ok {
+			return nil, fmt.Errorf("could not find allergy column %q in export file", name)
+		}
+	}
 	eventColumnIndex, ok := columnIndex["Event Name"]
 	if !ok {
 		return nil, errors.New("could not find Event Name column in export file")
 	}
 
+	cell := func(row []string, j int) string {
+		if j >= len(row) {
+			return ""
+		}
+		return row[j]
+	}
+
 	// Process body rows.
 
 	var (
 		participants []*model.Participant
 		p            *participant
 	)
-	for i := 1; ; i++ {
-		row, err := csvr.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, err
+	for _, row := range rows[1:] {
+		if len(row) == 0 {
+			continue
 		}
-
-		event := row[eventColumnIndex]
+		event := cell(row, eventColumnIndex)
 		if m := classNumberPattern.FindStringSubmatch(event); m != nil {
 			if p == nil {
 				return nil, errors.New("dk: found class row before PTC row")
@@ -163,12 +200,13 @@ func ParseCSV(rd io.Reader) ([]*model.Participant, error) {
 			p = &participant{}
 			participants = append(participants, &p.Participant)
 			for _, s := range setters {
-				j := columnIndex[s.name]
-				if j >= len(row) {
-					return nil, errors.New("dk: short row")
-				}
-				cell := strings.TrimSpace(row[j])
-				s.fn(p, cell)
+				s.fn(p, strings.TrimSpace(cell(row, columnIndex[s.name])))
+			}
+			p.SetDietaryTags(model.DietaryTagsFromColumns(dietaryTags, func(name string) string {
+				return strings.TrimSpace(cell(row, columnIndex[name]))
+			}))
+			for _, name := range conf.AllergyColumns {
+				addAllergies(p, strings.TrimSpace(cell(row, columnIndex[name])))
 			}
 			cleanParticipant(p)
 		}
@@ -278,7 +316,7 @@ func cleanParticipant(p *participant) {
 	}
 }
 
-func FetchCSV(ctx context.Context, url string, header http.Header) ([]*model.Participant, error) {
+func FetchCSV(ctx context.Context, url string, header http.Header, conf *model.Conference) ([]*model.Participant, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -292,11 +330,11 @@ func FetchCSV(ctx context.Context, url string, header http.Header) ([]*model.Par
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("error fetching %s: %s", url, http.StatusText(resp.StatusCode))
 	}
 
-	defer resp.Body.Close()
-	return ParseCSV(resp.Body)
+	return ParseCSV(resp.Body, conf)
 }
@@ -14,7 +14,7 @@ import (
 func main() {
 	log.SetFlags(0)
 	flag.Parse()
-	participants, err := dk.ParseCSV(os.Stdin)
+	participants, err := dk.ParseCSV(os.Stdin, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
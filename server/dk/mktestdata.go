@@ -37,7 +37,7 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	classes, err := sheet.GetClasses(context.Background(), config)
+	classes, err := sheet.GetClasses(context.Background(), sheet.NewSource(config))
 	if err != nil {
 		log.Fatal(err)
 	}
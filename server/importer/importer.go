@@ -0,0 +1,430 @@
+// Package importer parses registration exports using the column mappings in
+// a model.ImportProfile.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seaptc/server/model"
+)
+
+var stringFields = map[string]func(p *model.Participant) *string{
+	"registrationNumber":  func(p *model.Participant) *string { return &p.RegistrationNumber },
+	"registeredByName":    func(p *model.Participant) *string { return &p.RegisteredByName },
+	"registeredByEmail":   func(p *model.Participant) *string { return &p.RegisteredByEmail },
+	"registeredByPhone":   func(p *model.Participant) *string { return &p.RegisteredByPhone },
+	"firstName":           func(p *model.Participant) *string { return &p.FirstName },
+	"lastName":            func(p *model.Participant) *string { return &p.LastName },
+	"nickname":            func(p *model.Participant) *string { return &p.Nickname },
+	"suffix":              func(p *model.Participant) *string { return &p.Suffix },
+	"phone":               func(p *model.Participant) *string { return &p.Phone },
+	"email":               func(p *model.Participant) *string { return &p.Email },
+	"address":             func(p *model.Participant) *string { return &p.Address },
+	"city":                func(p *model.Participant) *string { return &p.City },
+	"state":               func(p *model.Participant) *string { return &p.State },
+	"zip":                 func(p *model.Participant) *string { return &p.Zip },
+	"staffRole":           func(p *model.Participant) *string { return &p.StaffRole },
+	"council":             func(p *model.Participant) *string { return &p.Council },
+	"district":            func(p *model.Participant) *string { return &p.District },
+	"unitType":            func(p *model.Participant) *string { return &p.UnitType },
+	"unitNumber":          func(p *model.Participant) *string { return &p.UnitNumber },
+	"dietaryRestrictions": func(p *model.Participant) *string { return &p.DietaryRestrictions },
+	"allergies":           func(p *model.Participant) *string { return &p.Allergies },
+	"marketing":           func(p *model.Participant) *string { return &p.Marketing },
+	"scoutingYears":       func(p *model.Participant) *string { return &p.ScoutingYears },
+	"bsaNumber":           func(p *model.Participant) *string { return &p.BSANumber },
+	"staffDescription":    func(p *model.Participant) *string { return &p.StaffDescription },
+}
+
+var boolFields = map[string]func(p *model.Participant) *bool{
+	"staff":      func(p *model.Participant) *bool { return &p.Staff },
+	"youth":      func(p *model.Participant) *bool { return &p.Youth },
+	"showQRCode": func(p *model.Participant) *bool { return &p.ShowQRCode },
+	"oaBanquet":  func(p *model.Participant) *bool { return &p.OABanquet },
+}
+
+var pseudoFields = map[string]func(p *model.Participant, s string){
+	"name": func(p *model.Participant, s string) {
+		f := strings.Fields(s)
+		if len(f) == 0 {
+			return
+		}
+		p.LastName = f[len(f)-1]
+		p.FirstName = strings.Join(f[:len(f)-1], " ")
+	},
+	"type": func(p *model.Participant, s string) {
+		p.Youth = p.Youth || strings.Contains(s, "Youth")
+		p.Staff = p.Staff || strings.Contains(s, "Staff")
+	},
+}
+
+// FieldNames returns the sorted names of the fields that columns can be
+// mapped to.
+func FieldNames() []string {
+	var names []string
+	for name := range stringFields {
+		names = append(names, name)
+	}
+	for name := range boolFields {
+		names = append(names, name)
+	}
+	for name := range pseudoFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type transform func(s string) string
+
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+func parseTransform(spec string) (transform, error) {
+	name, arg := spec, ""
+	hasArg := false
+	if i := strings.IndexByte(spec, '='); i >= 0 {
+		name, arg, hasArg = spec[:i], spec[i+1:], true
+	}
+	switch {
+	case name == "title" && !hasArg:
+		return titleCase, nil
+	case name == "lower" && !hasArg:
+		return strings.ToLower, nil
+	case name == "upper" && !hasArg:
+		return strings.ToUpper, nil
+	case name == "digits" && !hasArg:
+		return func(s string) string { return strings.TrimLeft(digitsPattern.FindString(s), "0") }, nil
+	case name == "bool" && !hasArg:
+		return func(s string) string { return boolString(isTrue(s)) }, nil
+	case name == "bool":
+		return func(s string) string { return boolString(strings.EqualFold(s, arg)) }, nil
+	case name == "value" && hasArg:
+		return func(s string) string {
+			if s == "" {
+				return ""
+			}
+			return arg
+		}, nil
+	case name == "prefix" && hasArg:
+		return func(s string) string { return strings.TrimSpace(strings.TrimPrefix(s, arg)) }, nil
+	case name == "split" && hasArg:
+		i := strings.LastIndexByte(arg, ':')
+		if i < 0 {
+			break
+		}
+		sep := arg[:i]
+		n, err := strconv.Atoi(arg[i+1:])
+		if err != nil || n < 0 || sep == "" {
+			break
+		}
+		return func(s string) string {
+			parts := strings.Split(s, sep)
+			if n >= len(parts) {
+				return ""
+			}
+			return strings.TrimSpace(parts[n])
+		}, nil
+	}
+	return nil, fmt.Errorf("importer: invalid transform %q", spec)
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func isTrue(s string) bool {
+	switch strings.ToLower(s) {
+	case "yes", "y", "true", "x", "1":
+		return true
+	}
+	return false
+}
+
+func titleCase(s string) string {
+	ls := strings.ToLower(s)
+	if s != ls && s != strings.ToUpper(s) {
+		// Use s if s is mixed case.
+		return s
+	}
+	return strings.Title(ls)
+}
+
+type column struct {
+	index      int
+	field      string
+	transforms []transform
+}
+
+type parser struct {
+	columns      []*column
+	classMode    string
+	classColumns []int
+	separator    string
+	keyColumns   []int
+}
+
+func newParser(profile *model.ImportProfile, header []string) (*parser, error) {
+	columnIndex := make(map[string]int)
+	for j, name := range header {
+		columnIndex[strings.TrimSpace(name)] = j
+	}
+
+	lookup := func(name string) (int, error) {
+		j, ok := columnIndex[name]
+		if !ok {
+			return 0, fmt.Errorf("could not find column %q in export file", name)
+		}
+		return j, nil
+	}
+
+	var p parser
+	for _, ic := range profile.Columns {
+		if stringFields[ic.Field] == nil && boolFields[ic.Field] == nil && pseudoFields[ic.Field] == nil {
+			return nil, fmt.Errorf("importer: unknown field %q for column %q", ic.Field, ic.Column)
+		}
+		j, err := lookup(ic.Column)
+		if err != nil {
+			if ic.Optional {
+				continue
+			}
+			return nil, err
+		}
+		c := &column{index: j, field: ic.Field}
+		for _, spec := range ic.Transforms {
+			t, err := parseTransform(spec)
+			if err != nil {
+				return nil, err
+			}
+			c.transforms = append(c.transforms, t)
+		}
+		p.columns = append(p.columns, c)
+	}
+
+	p.classMode = profile.Classes.Mode
+	switch p.classMode {
+	case "":
+		if len(profile.Classes.Columns) > 0 {
+			return nil, errors.New("importer: class mode not specified")
+		}
+	case model.ImportClassesList, model.ImportClassesColumns:
+	case model.ImportClassesRows:
+		if len(profile.Classes.KeyColumns) == 0 {
+			return nil, errors.New("importer: key columns required for rows class mode")
+		}
+	default:
+		return nil, fmt.Errorf("importer: unknown class mode %q", p.classMode)
+	}
+
+	for _, name := range profile.Classes.Columns {
+		j, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		p.classColumns = append(p.classColumns, j)
+	}
+	for _, name := range profile.Classes.KeyColumns {
+		j, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		p.keyColumns = append(p.keyColumns, j)
+	}
+
+	p.separator = profile.Classes.Separator
+	if p.separator == "" {
+		p.separator = ","
+	}
+	return &p, nil
+}
+
+func cell(row []string, j int) string {
+	if j >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[j])
+}
+
+func (p *parser) setFields(participant *model.Participant, row []string) {
+	for _, c := range p.columns {
+		s := cell(row, c.index)
+		for _, t := range c.transforms {
+			s = t(s)
+		}
+		if s == "" {
+			continue
+		}
+		if f := stringFields[c.field]; f != nil {
+			v := f(participant)
+			if *v == "" {
+				*v = s
+			} else {
+				*v = *v + "; " + s
+			}
+		} else if f := boolFields[c.field]; f != nil {
+			v := f(participant)
+			*v = *v || s == "true" || isTrue(s)
+		} else {
+			pseudoFields[c.field](participant, s)
+		}
+	}
+}
+
+var classNumberPattern = regexp.MustCompile(`^(\d\d\d)(?:\D|$)`)
+
+func addClass(participant *model.Participant, s string) {
+	m := classNumberPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch {
+	case n == model.OABanquetClassNumber:
+		participant.OABanquet = true
+	case n == model.NoClassClassNumber:
+	case !model.IsValidClassNumber(n):
+	default:
+		for _, c := range participant.Classes {
+			if c == n {
+				return
+			}
+		}
+		participant.Classes = append(participant.Classes, n)
+	}
+}
+
+func (p *parser) addClasses(participant *model.Participant, row []string) {
+	for _, j := range p.classColumns {
+		s := cell(row, j)
+		if p.classMode == model.ImportClassesList {
+			for _, part := range strings.Split(s, p.separator) {
+				addClass(participant, part)
+			}
+		} else {
+			addClass(participant, s)
+		}
+	}
+}
+
+func cleanParticipant(p *model.Participant) {
+	p.Email = strings.ToLower(p.Email)
+	p.RegisteredByEmail = strings.ToLower(p.RegisteredByEmail)
+	if strings.EqualFold(p.Nickname, p.FirstName) {
+		p.Nickname = ""
+	}
+	if !p.Staff {
+		p.StaffRole = ""
+	}
+	p.SetDietaryTags(p.DietaryTags())
+	sort.Ints(p.Classes)
+}
+
+// ParseRows parses the rows of an export using profile. The first row is the
+// header.
+func ParseRows(profile *model.ImportProfile, rows [][]string) ([]*model.Participant, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("importer: missing header row")
+	}
+	p, err := newParser(profile, rows[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		participants []*model.Participant
+		byKey        = make(map[string]*model.Participant)
+	)
+
+	for i, row := range rows[1:] {
+		blank := true
+		for _, s := range row {
+			if strings.TrimSpace(s) != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			continue
+		}
+
+		var key string
+		if p.classMode == model.ImportClassesRows {
+			var parts []string
+			for _, j := range p.keyColumns {
+				parts = append(parts, cell(row, j))
+			}
+			key = strings.Join(parts, "\x00")
+			if participant := byKey[key]; participant != nil {
+				p.addClasses(participant, row)
+				continue
+			}
+		}
+
+		participant := &model.Participant{}
+		p.setFields(participant, row)
+		if participant.FirstName == "" && participant.LastName == "" {
+			return nil, fmt.Errorf("importer: row %d: name not found", i+2)
+		}
+		p.addClasses(participant, row)
+		participants = append(participants, participant)
+		if key != "" {
+			byKey[key] = participant
+		}
+	}
+
+	for _, participant := range participants {
+		cleanParticipant(participant)
+	}
+	return participants, nil
+}
+
+// ParseCSV parses a CSV export using profile.
+func ParseCSV(profile *model.ImportProfile, rd io.Reader) ([]*model.Participant, error) {
+	csvr := csv.NewReader(rd)
+	csvr.FieldsPerRecord = -1
+	rows, err := csvr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		// Remove byte order mark written by Excel.
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return ParseRows(profile, rows)
+}
+
+// CheckProfiles returns an error if a profile has a missing or duplicate
+// name, an unknown field or transform, or an invalid class encoding.
+func CheckProfiles(profiles []*model.ImportProfile) error {
+	names := make(map[string]bool)
+	for _, profile := range profiles {
+		if profile.Name == "" {
+			return errors.New("importer: profile name is required")
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("importer: duplicate profile name %q", profile.Name)
+		}
+		names[profile.Name] = true
+
+		// Create parser with a header containing all of the columns.
+		var header []string
+		for _, c := range profile.Columns {
+			header = append(header, c.Column)
+		}
+		header = append(header, profile.Classes.Columns...)
+		header = append(header, profile.Classes.KeyColumns...)
+		if _, err := newParser(profile, header); err != nil {
+			return fmt.Errorf("%s: %v", profile.Name, err)
+		}
+	}
+	return nil
+}
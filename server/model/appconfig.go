@@ -19,8 +19,14 @@ type AppConfig struct {
 		Secret string `json:"secret" datastore:"secret,noindex"`
 	} `json:"loginClient" datastore:"loginClient,noindex"`
 
-	// Planning spreadsheet
+	// Planning spreadsheet. Writing codes to the spreadsheet requires the
+	// service account to have edit access to the spreadsheet.
 	ClassesSheetURL                string `json:"classesSheetURL" datastore:"classesSheetURL,noindex,omitempty"`
 	SuggestedSchedulesSheetURL     string `json:"suggestedScheduleSheetURL" datastore:"suggestedScheduleSheetURL,noindex,omitempty"`
 	PlanningSheetServiceAccountKey string `json:"planningSheetServiceAccountKey" datastore:"planningSheetServiceAccountKey,noindex"`
+
+	// Local planning sheet files in CSV, XLSX or JSON format. The files are
+	// used instead of the Google Sheets when ClassesFile is set.
+	ClassesFile            string `json:"classesFile" datastore:"classesFile,noindex,omitempty"`
+	SuggestedSchedulesFile string `json:"suggestedSchedulesFile" datastore:"suggestedSchedulesFile,noindex,omitempty"`
 }
@@ -0,0 +1,41 @@
+package model
+
+// CertificateTemplate is the text of a training completion certificate.
+// Classes that grant training credit name a template in Class.Certificate.
+type CertificateTemplate struct {
+	Name  string `json:"name" datastore:"name,noindex"`
+	Title string `json:"title" datastore:"title,noindex"`
+
+	// Text is printed below the participant's name. The text is a Go
+	// template executed with the fields Name, Class, ClassNumber, Council,
+	// Date and Year. Each line is centered on the page.
+	Text string `json:"text" datastore:"text,noindex"`
+
+	// Captions for signature lines at the bottom of the certificate.
+	Signatures []string `json:"signatures" datastore:"signatures,noindex"`
+}
+
+// DefaultCertificateTemplate is used for classes that name a template not
+// found in the conference.
+var DefaultCertificateTemplate = &CertificateTemplate{
+	Title: "Certificate of Training",
+	Text:  "has completed\n{{.Class}}\nat the {{.Year}} Program and Training Conference",
+	Signatures: []string{
+		"Council Training Chair",
+		"Conference Chair",
+	},
+}
+
+// CertificateTemplate returns the certificate template with the given name.
+// The default template is returned if the conference does not have a
+// template with the name.
+func (c *Conference) CertificateTemplate(name string) *CertificateTemplate {
+	for _, t := range c.CertificateTemplates {
+		if t.Name == name {
+			return t
+		}
+	}
+	t := *DefaultCertificateTemplate
+	t.Name = name
+	return &t
+}
@@ -32,6 +32,11 @@ type Class struct {
 	InstructorNames  string `json:"instructorNames" datastore:"instructorNames" fields:"Import"`
 	InstructorEmails string `json:"instructorEmails" datastore:"instructorEmails" fields:"Import"`
 	EvaluationCodes  string `json:"evaluationCodes" datastore:"evaluationCodes" fields:"Import"`
+	RoomFeatures     string `json:"roomFeatures" datastore:"roomFeatures,noindex" fields:"Import"`
+
+	// Name of the certificate template for classes that grant training
+	// credit. Blank if the class does not grant credit.
+	Certificate string `json:"certificate" datastore:"certificate,noindex" fields:"Import"`
 
 	// Hash computed from planning spreadhseet fields.
 	ImportHash string `datastore:"importHash"`
@@ -97,10 +102,11 @@ func SplitComma(s string) []string {
 		i := strings.IndexByte(s, ',')
 		t := s
 		if i < 0 {
-			i = len(s)
+			s = ""
+		} else {
+			t = s[:i]
+			s = s[i+1:]
 		}
-		t = s[:i]
-		s = s[i+1:]
 		t = strings.TrimRight(t, " ")
 		if t != "" {
 			result = append(result, t)
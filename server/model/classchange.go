@@ -0,0 +1,187 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+//go:generate go run gogen.go -input classchange.go -output gen_classchange.go ClassChange
+
+// Class change request statuses.
+const (
+	// The request is waiting for staff approval.
+	ClassChangePending = "pending"
+
+	// Staff approved the request and the change was applied to the
+	// participant's classes.
+	ClassChangeApproved = "approved"
+
+	// Staff denied the request.
+	ClassChangeDenied = "denied"
+
+	// The participant canceled the request.
+	ClassChangeCanceled = "canceled"
+)
+
+// ClassChange is a participant's request to replace a class in their
+// schedule with another class.
+type ClassChange struct {
+	ID      int64     `json:"id" datastore:"-"`
+	Created time.Time `json:"created" datastore:"created"`
+	Updated time.Time `json:"updated" datastore:"updated,noindex"`
+
+	ParticipantID   string `json:"participantID" datastore:"participantID"`
+	ParticipantName string `json:"participantName" datastore:"participantName,noindex"`
+
+	// Class to drop. Zero if the participant has no class in the sessions
+	// of the added class.
+	Drop int `json:"drop" datastore:"drop,noindex"`
+
+	// Class to add.
+	Add int `json:"add" datastore:"add,noindex"`
+
+	// Comment from the participant.
+	Comment string `json:"comment" datastore:"comment,noindex"`
+
+	Status string `json:"status" datastore:"status"`
+
+	// ID of the staff member who approved or denied the request and the
+	// reason given to the participant.
+	StaffID string `json:"staffID" datastore:"staffID,noindex"`
+	Reason  string `json:"reason" datastore:"reason,noindex"`
+}
+
+// IsPending returns true if the request is waiting for staff approval.
+func (cc *ClassChange) IsPending() bool {
+	return cc.Status == ClassChangePending
+}
+
+// Apply returns the participant's classes after the change.
+func (cc *ClassChange) Apply(classes []int) []int {
+	var result []int
+	for _, n := range classes {
+		if n != cc.Drop && n != cc.Add {
+			result = append(result, n)
+		}
+	}
+	result = append(result, cc.Add)
+	sort.Ints(result)
+	return result
+}
+
+// ApplyClassChange applies the change to the participant's classes. The
+// change is recorded as an override on the classes until a registration
+// import confirms the change.
+func (p *Participant) ApplyClassChange(cc *ClassChange, now time.Time) {
+	p.SetOverride("classes", formatClasses(cc.Apply(p.Classes)), now)
+}
+
+// CheckClassChange returns a list of reasons that the change cannot be made
+// to the participant's schedule. The registered map is the number of
+// participants registered for each class.
+func (ci *ClassInfo) CheckClassChange(p *Participant, cc *ClassChange, registered map[int]int) []string {
+	var problems []string
+
+	if cc.Drop != 0 && !containsInt(p.Classes, cc.Drop) {
+		problems = append(problems, fmt.Sprintf("Not registered for class %d.", cc.Drop))
+	}
+
+	c := ci.LookupNumber(cc.Add)
+	if c == nil {
+		return append(problems, fmt.Sprintf("Class %d not found.", cc.Add))
+	}
+	if containsInt(p.Classes, cc.Add) {
+		problems = append(problems, fmt.Sprintf("Already registered for class %d.", cc.Add))
+	}
+
+	if c.Capacity < 0 || (c.Capacity > 0 && registered[c.Number] >= c.Capacity) {
+		problems = append(problems, fmt.Sprintf("Class %d is full.", c.Number))
+	}
+
+	if pd := p.Program(); pd != nil && c.Programs != 0 && !c.ForProgram(pd) {
+		problems = append(problems, fmt.Sprintf("Class %d is not offered for %s.", c.Number, pd.Name))
+	}
+
+	start, end := c.StartEnd()
+	if end >= NumSession {
+		problems = append(problems, fmt.Sprintf("Class %d ends after the last session.", c.Number))
+	}
+	for _, n := range p.Classes {
+		if n == cc.Drop || n == cc.Add {
+			continue
+		}
+		x := ci.LookupNumber(n)
+		if x == nil {
+			continue
+		}
+		xstart, xend := x.StartEnd()
+		if start <= xend && xstart <= end {
+			problems = append(problems, fmt.Sprintf("Class %d overlaps class %d.", c.Number, x.Number))
+		}
+	}
+	for _, ic := range p.InstructorClasses {
+		if start <= ic.Session && ic.Session <= end {
+			problems = append(problems, fmt.Sprintf("Class %d overlaps an instructor assignment in session %d.", c.Number, ic.Session+1))
+		}
+	}
+	return problems
+}
+
+// ForProgram returns true if the class is offered for the program.
+func (c *Class) ForProgram(pd *ProgramDescription) bool {
+	for i := 0; i < NumPrograms; i++ {
+		if ProgramDescriptions[i] == pd {
+			return c.offeredFor(i)
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, n int) bool {
+	for _, v := range values {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// formatClasses returns the class numbers in ascending order separated by
+// commas.
+func formatClasses(classes []int) string {
+	numbers := append([]int(nil), classes...)
+	sort.Ints(numbers)
+	s := make([]string, len(numbers))
+	for i, n := range numbers {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+func parseClasses(s string) []int {
+	var result []int
+	for _, f := range SplitComma(s) {
+		if n, err := strconv.Atoi(f); err == nil {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func (cc *ClassChange) Load(ps []datastore.Property) error {
+	return datastore.LoadStruct(cc, ps)
+}
+
+func (cc *ClassChange) LoadKey(k *datastore.Key) error {
+	cc.ID = k.ID
+	return nil
+}
+
+func (cc *ClassChange) Save() ([]datastore.Property, error) {
+	return datastore.SaveStruct(cc)
+}
@@ -15,7 +15,18 @@ type Lunch struct {
 	// 1: first, 2: second
 	Seating int `json:"seating" datastore:"seating,noindex"`
 
-	// If participant is taking one of these classes then
+	// Seats available at the location for this seating. Zero if the
+	// capacity is not known. Lunches at the same location and seating share
+	// the sum of their capacities.
+	Capacity int `json:"capacity,omitempty" datastore:"capacity,noindex,omitempty"`
+
+	// Caterer contact for the lunch order.
+	Caterer      string `json:"caterer,omitempty" datastore:"caterer,noindex,omitempty"`
+	CatererEmail string `json:"catererEmail,omitempty" datastore:"catererEmail,noindex,omitempty"`
+
+	// If a LunchRule matches the participant then
+	//  pick up lunch at the rule's lunch
+	// else if participant is taking one of these classes then
 	//  pick up lunch here
 	// else if participant is in one of these unit types then
 	//  pick up lunch here
@@ -32,6 +43,9 @@ type Conference struct {
 	// First lunch is default choice
 	Lunches []*Lunch `json:"lunches" datastore:"lunches,noindex"`
 
+	// Rules checked before the class and unit type selectors on Lunches.
+	LunchRules []*LunchRule `json:"lunchRules" datastore:"lunchRules,noindex"`
+
 	RegistrationURL string `json:"registrationURL" datastore:"registrationURL,noindex,omitempty"`
 
 	// Use this message to announce when registration will open or that the
@@ -47,15 +61,62 @@ type Conference struct {
 	OABanquetLocation string `json:"oaBanquetLocation" datastore:"oaBanquetLocation,noindex"`
 	OpeningLocation   string `json:"openingLocation" datastore:"openingLocation,noindex"`
 
+	// URL of the Doubleknot registration export for scheduled imports. The
+	// headers are "Name: value" lines sent with the request, typically the
+	// session cookie.
+	RegistrationExportURL     string `json:"registrationExportURL" datastore:"registrationExportURL,noindex,omitempty"`
+	RegistrationExportHeaders string `json:"registrationExportHeaders" datastore:"registrationExportHeaders,noindex,omitempty"`
+
+	// Column mappings for registration exports from other systems.
+	ImportProfiles []*ImportProfile `json:"importProfiles" datastore:"importProfiles,noindex"`
+
+	// Room inventory for the room assignment solver.
+	Rooms []*Room `json:"rooms" datastore:"rooms,noindex"`
+
+	// Templates for training completion certificates.
+	CertificateTemplates []*CertificateTemplate `json:"certificateTemplates" datastore:"certificateTemplates,noindex"`
+
+	// Course codes for training record exports.
+	TrainingCourses []*TrainingCourse `json:"trainingCourses" datastore:"trainingCourses,noindex"`
+
+	// Sticker and badge sheet layouts for PDF printing.
+	LabelTemplates []*LabelTemplate `json:"labelTemplates" datastore:"labelTemplates,noindex"`
+
+	// Dietary tags and the Doubleknot export columns that select them.
+	// DefaultDietaryTags is used if empty.
+	DietaryTags []*DietaryTag `json:"dietaryTags" datastore:"dietaryTags,noindex"`
+
+	// Doubleknot export columns with free-text allergy notes.
+	AllergyColumns []string `json:"allergyColumns" datastore:"allergyColumns,noindex"`
+
+	// Percentage added to the regular meals in caterer orders.
+	LunchBuffer int `json:"lunchBuffer" datastore:"lunchBuffer,noindex,omitempty"`
+
+	// Accept class change requests from participants. The participant site
+	// is open to registered participants before the conference when set.
+	ClassChanges bool `json:"classChanges" datastore:"classChanges,noindex,omitempty"`
+
 	once     sync.Once
 	staffMap map[string]bool
 	lunch    struct {
 		def        *Lunch
 		byClass    map[int]*Lunch
 		byUnitType map[string]*Lunch
+		rules      []*LunchRule
 	}
 }
 
+// ImportProfile returns the import profile with the given name or nil if not
+// found.
+func (c *Conference) ImportProfile(name string) *ImportProfile {
+	for _, p := range c.ImportProfiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
 func (c *Conference) IsStaff(id string) bool {
 	c.setup()
 	return c.staffMap[id]
@@ -67,6 +128,7 @@ func (c *Conference) setup() {
 		for _, id := range strings.Fields(c.StaffIDs) {
 			c.staffMap[strings.ToLower(id)] = true
 		}
+		c.lunch.rules = sortedLunchRules(c.LunchRules)
 		c.lunch.byClass = make(map[int]*Lunch)
 		c.lunch.byUnitType = make(map[string]*Lunch)
 		for _, l := range c.Lunches {
@@ -99,12 +161,40 @@ func (c *Conference) ClassLunch(class *Class) *Lunch {
 }
 
 func (c *Conference) ParticipantLunch(p *Participant) *Lunch {
+	l, _ := c.participantLunch(p)
+	return l
+}
+
+// ParticipantLunchRule returns the rule that assigns the participant's
+// lunch or nil if no rule assigns the lunch.
+func (c *Conference) ParticipantLunchRule(p *Participant) *LunchRule {
+	_, r := c.participantLunch(p)
+	return r
+}
+
+// participantLunch returns the participant's lunch and the rule that
+// assigns the lunch. Seating balance rules are checked after the class
+// lunches so that a class added after the seatings were balanced sets the
+// lunch.
+func (c *Conference) participantLunch(p *Participant) (*Lunch, *LunchRule) {
 	c.setup()
+	var balance *LunchRule
+	for _, r := range c.lunch.rules {
+		if !r.Matches(p) {
+			continue
+		}
+		if r.Balance {
+			balance = r
+		} else if l := c.Lunch(r.Lunch); l != nil {
+			return l, r
+		}
+		break
+	}
 	var skipClasses bool
 	for _, ic := range p.InstructorClasses {
 		if ic.Session == LunchSession {
 			if l, ok := c.lunch.byClass[ic.Class]; ok {
-				return l
+				return l, nil
 			}
 			skipClasses = true
 			break
@@ -113,15 +203,20 @@ func (c *Conference) ParticipantLunch(p *Participant) *Lunch {
 	if !skipClasses {
 		for _, class := range p.Classes {
 			if l, ok := c.lunch.byClass[class]; ok {
-				return l
+				return l, nil
+			}
+		}
+		if balance != nil {
+			if l := c.Lunch(balance.Lunch); l != nil {
+				return l, balance
 			}
 		}
 	}
 	if l, ok := c.lunch.byUnitType[p.UnitType]; ok {
-		return l
+		return l, nil
 	}
 	if len(c.Lunches) == 0 {
-		return tbdLunch
+		return tbdLunch, nil
 	}
-	return c.Lunches[0]
+	return c.Lunches[0], nil
 }
@@ -0,0 +1,246 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dietary tag severities, least severe first.
+const (
+	// A preference such as vegetarian. The caterer provides a different
+	// meal.
+	DietaryPreference = "preference"
+
+	// An intolerance such as gluten free. The meal must not include the
+	// ingredient.
+	DietaryIntolerance = "intolerance"
+
+	// An allergy. The meal must be prepared separately and checked at pickup.
+	DietaryAllergy = "allergy"
+)
+
+var dietarySeverityRank = map[string]int{
+	"":                 0,
+	DietaryPreference:  1,
+	DietaryIntolerance: 2,
+	DietaryAllergy:     3,
+}
+
+// DietarySeverityRank returns a number that increases with severity.
+func DietarySeverityRank(severity string) int {
+	return dietarySeverityRank[severity]
+}
+
+// AllergyNotesTag is the name of the pseudo tag used to count participants
+// with allergy notes.
+const AllergyNotesTag = "Allergy notes"
+
+// DietaryTag is a dietary requirement that can be selected at registration.
+type DietaryTag struct {
+	Name     string `json:"name" datastore:"name,noindex"`
+	Severity string `json:"severity" datastore:"severity,noindex"`
+
+	// Names of the Doubleknot export columns that select the tag. The tag
+	// is set when any of the columns is not blank.
+	Columns []string `json:"columns" datastore:"columns,noindex"`
+
+	// Tags that are removed when this tag is set. Vegan supersedes
+	// vegetarian.
+	Supersedes []string `json:"supersedes,omitempty" datastore:"supersedes,noindex"`
+}
+
+// DefaultDietaryTags is used when the conference does not specify dietary
+// tags. The columns are the Doubleknot meal requirement checkboxes.
+var DefaultDietaryTags = []*DietaryTag{
+	{Name: "Vegan", Severity: DietaryPreference, Columns: []string{"Do you have any meal requirements?:Vegan"}, Supersedes: []string{"Vegetarian"}},
+	{Name: "Vegetarian", Severity: DietaryPreference, Columns: []string{"Do you have any meal requirements?:Vegetarian"}},
+	{Name: "Gluten Free", Severity: DietaryIntolerance, Columns: []string{"Do you have any meal requirements?:Gluten Free"}},
+}
+
+// DietaryTagList returns the conference dietary tags or the default tags if
+// the conference does not specify tags.
+func (c *Conference) DietaryTagList() []*DietaryTag {
+	if len(c.DietaryTags) > 0 {
+		return c.DietaryTags
+	}
+	return DefaultDietaryTags
+}
+
+// DietaryTag returns the tag with the given name. Tags not in the
+// configuration, for example tags set by an import profile, are returned as
+// preferences.
+func (c *Conference) DietaryTag(name string) *DietaryTag {
+	for _, t := range c.DietaryTagList() {
+		if strings.EqualFold(t.Name, name) {
+			return t
+		}
+	}
+	return &DietaryTag{Name: name, Severity: DietaryPreference}
+}
+
+// DietarySeverity returns the most severe of the participant's dietary tags
+// and allergy notes or "" if the participant does not have dietary
+// restrictions.
+func (c *Conference) DietarySeverity(p *Participant) string {
+	if p.Allergies != "" {
+		return DietaryAllergy
+	}
+	severity := ""
+	for _, name := range p.DietaryTags() {
+		if s := c.DietaryTag(name).Severity; dietarySeverityRank[s] > dietarySeverityRank[severity] {
+			severity = s
+		}
+	}
+	return severity
+}
+
+// SortDietary sorts participants by dietary severity, most severe first,
+// and then by tags.
+func (c *Conference) SortDietary(participants []*Participant) {
+	sort.SliceStable(participants, func(i, j int) bool {
+		ri := DietarySeverityRank(c.DietarySeverity(participants[i]))
+		rj := DietarySeverityRank(c.DietarySeverity(participants[j]))
+		if ri != rj {
+			return ri > rj
+		}
+		return participants[i].DietaryRestrictions < participants[j].DietaryRestrictions
+	})
+}
+
+// CheckDietaryTags returns a list of problems with the dietary tag
+// configuration.
+func CheckDietaryTags(tags []*DietaryTag) []string {
+	var problems []string
+	names := make(map[string]bool)
+	for _, t := range tags {
+		switch {
+		case t.Name == "":
+			problems = append(problems, "Dietary tag name is required.")
+		case strings.Contains(t.Name, ";"):
+			problems = append(problems, fmt.Sprintf("Dietary tag %q: name must not contain \";\".", t.Name))
+		case names[strings.ToLower(t.Name)]:
+			problems = append(problems, fmt.Sprintf("Dietary tag %q is listed more than once.", t.Name))
+		}
+		names[strings.ToLower(t.Name)] = true
+		if t.Severity != DietaryPreference && t.Severity != DietaryIntolerance && t.Severity != DietaryAllergy {
+			problems = append(problems, fmt.Sprintf("Dietary tag %q: severity must be %q, %q or %q.", t.Name, DietaryPreference, DietaryIntolerance, DietaryAllergy))
+		}
+	}
+	for _, t := range tags {
+		for _, name := range t.Supersedes {
+			if !names[strings.ToLower(name)] {
+				problems = append(problems, fmt.Sprintf("Dietary tag %q: superseded tag %q not found.", t.Name, name))
+			}
+		}
+	}
+	return problems
+}
+
+// DietaryTags returns the participant's dietary tags.
+func (p *Participant) DietaryTags() []string {
+	if p.DietaryRestrictions == "" {
+		return nil
+	}
+	return strings.Split(p.DietaryRestrictions, "; ")
+}
+
+// SetDietaryTags sets the participant's dietary tags. Blank and duplicate
+// tags are ignored.
+func (p *Participant) SetDietaryTags(tags []string) {
+	var result []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t != "" && !containsFold(result, t) {
+			result = append(result, t)
+		}
+	}
+	p.DietaryRestrictions = strings.Join(result, "; ")
+}
+
+// HasDietaryTag returns true if the participant has the tag.
+func (p *Participant) HasDietaryTag(name string) bool {
+	return containsFold(p.DietaryTags(), name)
+}
+
+// HasDietaryNeeds returns true if the participant has dietary tags or
+// allergy notes.
+func (p *Participant) HasDietaryNeeds() bool {
+	return p.DietaryRestrictions != "" || p.Allergies != ""
+}
+
+// DietarySummary returns the participant's tags and allergy notes for
+// display.
+func (p *Participant) DietarySummary() string {
+	switch {
+	case p.Allergies == "":
+		return p.DietaryRestrictions
+	case p.DietaryRestrictions == "":
+		return "Allergy: " + p.Allergies
+	default:
+		return p.DietaryRestrictions + "; Allergy: " + p.Allergies
+	}
+}
+
+// DietaryTagCount is the number of participants with a dietary tag.
+type DietaryTagCount struct {
+	Tag   *DietaryTag
+	Count int
+}
+
+// CountDietaryTags returns the number of participants with each tag. The
+// configured tags are returned first in configuration order followed by any
+// other tags found on participants. Participants with allergy notes are
+// counted with the pseudo tag AllergyNotesTag.
+func (c *Conference) CountDietaryTags(participants []*Participant) []*DietaryTagCount {
+	var result []*DietaryTagCount
+	byName := make(map[string]*DietaryTagCount)
+	add := func(t *DietaryTag) *DietaryTagCount {
+		tc := byName[strings.ToLower(t.Name)]
+		if tc == nil {
+			tc = &DietaryTagCount{Tag: t}
+			byName[strings.ToLower(t.Name)] = tc
+			result = append(result, tc)
+		}
+		return tc
+	}
+	for _, t := range c.DietaryTagList() {
+		add(t)
+	}
+	allergies := &DietaryTagCount{Tag: &DietaryTag{Name: AllergyNotesTag, Severity: DietaryAllergy}}
+	for _, p := range participants {
+		for _, name := range p.DietaryTags() {
+			add(c.DietaryTag(name)).Count++
+		}
+		if p.Allergies != "" {
+			allergies.Count++
+		}
+	}
+	return append(result, allergies)
+}
+
+// DietaryTagsFromColumns returns the dietary tags selected by the values of
+// the named columns. The function value returns the value of a column.
+func DietaryTagsFromColumns(tags []*DietaryTag, value func(column string) string) []string {
+	var result []string
+	superseded := make(map[string]bool)
+	for _, t := range tags {
+		for _, column := range t.Columns {
+			if value(column) != "" {
+				result = append(result, t.Name)
+				for _, name := range t.Supersedes {
+					superseded[strings.ToLower(name)] = true
+				}
+				break
+			}
+		}
+	}
+	i := 0
+	for _, name := range result {
+		if !superseded[strings.ToLower(name)] {
+			result[i] = name
+			i++
+		}
+	}
+	return result[:i]
+}
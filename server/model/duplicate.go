@@ -0,0 +1,244 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DuplicateParticipants is a pair of participants that may be the same
+// person.
+type DuplicateParticipants struct {
+	A, B *Participant
+
+	// Why the participants match, for example "same email".
+	Reasons []string
+
+	// Higher scores are more likely to be duplicates.
+	Score int
+}
+
+type duplicateKey struct {
+	p      *Participant
+	first  []string
+	last   string
+	emails []string
+	phone  string
+}
+
+func newDuplicateKey(p *Participant) *duplicateKey {
+	k := &duplicateKey{
+		p:     p,
+		last:  normalizeName(p.LastName),
+		phone: normalizePhone(p.Phone),
+	}
+	for _, s := range []string{p.FirstName, p.Nickname} {
+		if s := normalizeName(s); s != "" {
+			k.first = append(k.first, s)
+		}
+	}
+	for _, e := range p.Emails() {
+		if e := strings.ToLower(strings.TrimSpace(e)); e != "" {
+			k.emails = append(k.emails, e)
+		}
+	}
+	return k
+}
+
+// normalizeName returns the lower case letters in s.
+func normalizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// normalizePhone returns the last ten digits in s.
+func normalizePhone(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(s) > 10 {
+		s = s[len(s)-10:]
+	}
+	return s
+}
+
+// similarName returns true if the names differ by a small typo or one name
+// is a short form of the other, for example Dan and Daniel.
+func similarName(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	if len(a) >= 3 && len(b) >= 3 && (strings.HasPrefix(a, b) || strings.HasPrefix(b, a)) {
+		return true
+	}
+	max := 1
+	if len(a) > 5 && len(b) > 5 {
+		max = 2
+	}
+	return editDistance(a, b) <= max
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func (k *duplicateKey) match(x *duplicateKey) *DuplicateParticipants {
+	firstExact, firstSimilar := false, false
+	for _, a := range k.first {
+		for _, b := range x.first {
+			firstExact = firstExact || a == b
+			firstSimilar = firstSimilar || similarName(a, b)
+		}
+	}
+	if !firstSimilar {
+		// Family members share last names, emails and phones.
+		return nil
+	}
+
+	d := &DuplicateParticipants{A: k.p, B: x.p}
+	lastSimilar := similarName(k.last, x.last)
+	switch {
+	case firstExact && k.last == x.last:
+		d.Score += 3
+		d.Reasons = append(d.Reasons, "same name")
+	case lastSimilar:
+		d.Score += 2
+		d.Reasons = append(d.Reasons, "similar name")
+	}
+
+	contact := false
+	for _, a := range k.emails {
+		for _, b := range x.emails {
+			if a == b && !contact {
+				contact = true
+				d.Score++
+				d.Reasons = append(d.Reasons, "same email")
+			}
+		}
+	}
+	if k.phone != "" && k.phone == x.phone {
+		contact = true
+		d.Score++
+		d.Reasons = append(d.Reasons, "same phone")
+	}
+
+	if !lastSimilar && !contact {
+		return nil
+	}
+	return d
+}
+
+// FindDuplicateParticipants returns pairs of participants with similar
+// first names and either similar last names or the same email or phone. The
+// pairs are sorted by decreasing score.
+func FindDuplicateParticipants(participants []*Participant) []*DuplicateParticipants {
+	keys := make([]*duplicateKey, len(participants))
+	for i, p := range participants {
+		keys[i] = newDuplicateKey(p)
+	}
+	var result []*DuplicateParticipants
+	for i, k := range keys {
+		for _, x := range keys[i+1:] {
+			if d := k.match(x); d != nil {
+				result = append(result, d)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].A.sortName < result[j].A.sortName
+	})
+	return result
+}
+
+// MergeDuplicate moves the notes, instructor classes and profile edits of
+// participant d to p and records d's ID and login code as merged into p.
+// Instructor classes and profile edits of p are kept when both participants
+// teach in the same session or edited the same field.
+func (p *Participant) MergeDuplicate(d *Participant, now time.Time) {
+	if d.Notes != "" && !strings.Contains(p.Notes, d.Notes) {
+		p.Notes = strings.TrimSpace(p.Notes + "\n" + d.Notes)
+	}
+	for _, ic := range d.InstructorClasses {
+		found := false
+		for _, x := range p.InstructorClasses {
+			found = found || x.Session == ic.Session
+		}
+		if !found {
+			p.InstructorClasses = append(p.InstructorClasses, ic)
+		}
+	}
+	for _, o := range d.Overrides {
+		if overrideFields[o.Field] != nil && p.Override(o.Field) == nil {
+			p.SetOverride(o.Field, o.Value, now)
+		}
+	}
+	p.MergedIDs = appendMissing(p.MergedIDs, p.ID, append(d.MergedIDs, d.ID)...)
+	p.MergedLoginCodes = appendMissing(p.MergedLoginCodes, p.LoginCode, append(d.MergedLoginCodes, d.LoginCode)...)
+	SortInstructorClasses(p.InstructorClasses)
+	if p.CheckinTime.IsZero() || (!d.CheckinTime.IsZero() && d.CheckinTime.Before(p.CheckinTime)) {
+		p.CheckinTime = d.CheckinTime
+	}
+}
+
+// AddMergedLoginCode records code as merged into p so that the code
+// continues to work.
+func (p *Participant) AddMergedLoginCode(code string) {
+	p.MergedLoginCodes = appendMissing(p.MergedLoginCodes, p.LoginCode, code)
+}
+
+// appendMissing appends the values that are not blank, not equal to self
+// and not already in list.
+func appendMissing(list []string, self string, values ...string) []string {
+	for _, v := range values {
+		found := v == "" || v == self
+		for _, x := range list {
+			found = found || x == v
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
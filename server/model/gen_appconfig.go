@@ -4,6 +4,7 @@ package model
 
 const (
 	AppConfig_AdminIDs                       = "adminIDs"
+	AppConfig_ClassesFile                    = "classesFile"
 	AppConfig_ClassesSheetURL                = "classesSheetURL"
 	AppConfig_Day                            = "day"
 	AppConfig_HMACKeys                       = "hmacKeys"
@@ -11,6 +12,7 @@ const (
 	AppConfig_Month                          = "month"
 	AppConfig_PlanningSheetServiceAccountKey = "planningSheetServiceAccountKey"
 	AppConfig_StaffIDs                       = "staffIDs"
+	AppConfig_SuggestedSchedulesFile         = "suggestedSchedulesFile"
 	AppConfig_SuggestedSchedulesSheetURL     = "suggestedScheduleSheetURL"
 	AppConfig_XSRFKey                        = "xsrfKey"
 	AppConfig_Year                           = "year"
@@ -10,6 +10,7 @@ import (
 const (
 	Class_AccessToken      = "accessToken"
 	Class_Capacity         = "capacity"
+	Class_Certificate      = "certificate"
 	Class_Description      = "description"
 	Class_EvaluationCodes  = "evaluationCodes"
 	Class_ImportHash       = "importHash"
@@ -21,6 +22,7 @@ const (
 	Class_Number           = "_"
 	Class_Programs         = "programs"
 	Class_Responsibility   = "responsibility"
+	Class_RoomFeatures     = "roomFeatures"
 	Class_SpreadsheetRow   = "spreadsheetRow"
 	Class_Title            = "title"
 	Class_TitleNote        = "titleNote"
@@ -29,6 +31,7 @@ const (
 func (x *Class) CopyImportFieldsTo(y *Class) {
 	y.AccessToken = x.AccessToken
 	y.Capacity = x.Capacity
+	y.Certificate = x.Certificate
 	y.Description = x.Description
 	y.EvaluationCodes = x.EvaluationCodes
 	y.InstructorEmails = x.InstructorEmails
@@ -39,6 +42,7 @@ func (x *Class) CopyImportFieldsTo(y *Class) {
 	y.Number = x.Number
 	y.Programs = x.Programs
 	y.Responsibility = x.Responsibility
+	y.RoomFeatures = x.RoomFeatures
 	y.SpreadsheetRow = x.SpreadsheetRow
 	y.Title = x.Title
 	y.TitleNote = x.TitleNote
@@ -47,6 +51,7 @@ func (x *Class) CopyImportFieldsTo(y *Class) {
 func (x *Class) EqualImportFields(y *Class) bool {
 	return x.AccessToken == y.AccessToken &&
 		x.Capacity == y.Capacity &&
+		x.Certificate == y.Certificate &&
 		x.Description == y.Description &&
 		x.EvaluationCodes == y.EvaluationCodes &&
 		x.InstructorEmails == y.InstructorEmails &&
@@ -57,6 +62,7 @@ func (x *Class) EqualImportFields(y *Class) bool {
 		x.Number == y.Number &&
 		x.Programs == y.Programs &&
 		x.Responsibility == y.Responsibility &&
+		x.RoomFeatures == y.RoomFeatures &&
 		x.SpreadsheetRow == y.SpreadsheetRow &&
 		x.Title == y.Title &&
 		x.TitleNote == y.TitleNote
@@ -64,9 +70,10 @@ func (x *Class) EqualImportFields(y *Class) bool {
 
 func (x *Class) HashImportFields() string {
 	h := md5.New()
-	hashValue(h, "bc11beba53e3b91809849e58d8a81de1")
+	hashValue(h, "62301e6cd4906cc337f9918415541c34")
 	hashValue(h, x.AccessToken)
 	hashValue(h, x.Capacity)
+	hashValue(h, x.Certificate)
 	hashValue(h, x.Description)
 	hashValue(h, x.EvaluationCodes)
 	hashValue(h, x.InstructorEmails)
@@ -77,6 +84,7 @@ func (x *Class) HashImportFields() string {
 	hashValue(h, x.Number)
 	hashValue(h, x.Programs)
 	hashValue(h, x.Responsibility)
+	hashValue(h, x.RoomFeatures)
 	hashValue(h, x.SpreadsheetRow)
 	hashValue(h, x.Title)
 	hashValue(h, x.TitleNote)
@@ -0,0 +1,16 @@
+// Code generated by gogen.go; DO NOT EDIT.
+
+package model
+
+const (
+	ClassChange_Add             = "add"
+	ClassChange_Comment         = "comment"
+	ClassChange_Created         = "created"
+	ClassChange_Drop            = "drop"
+	ClassChange_ParticipantID   = "participantID"
+	ClassChange_ParticipantName = "participantName"
+	ClassChange_Reason          = "reason"
+	ClassChange_StaffID         = "staffID"
+	ClassChange_Status          = "status"
+	ClassChange_Updated         = "updated"
+)
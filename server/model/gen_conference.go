@@ -3,12 +3,24 @@
 package model
 
 const (
-	Conference_CatalogStatusMessage = "catalogStatusMessage"
-	Conference_Lunches              = "lunches"
-	Conference_NoClassDescription   = "noClassDescription"
-	Conference_OABanquetDescription = "oaBanquetDescription"
-	Conference_OABanquetLocation    = "oaBanquetLocation"
-	Conference_OpeningLocation      = "openingLocation"
-	Conference_RegistrationURL      = "registrationURL"
-	Conference_StaffIDs             = "staffIDs"
+	Conference_AllergyColumns            = "allergyColumns"
+	Conference_CatalogStatusMessage      = "catalogStatusMessage"
+	Conference_CertificateTemplates      = "certificateTemplates"
+	Conference_ClassChanges              = "classChanges"
+	Conference_DietaryTags               = "dietaryTags"
+	Conference_ImportProfiles            = "importProfiles"
+	Conference_LabelTemplates            = "labelTemplates"
+	Conference_LunchBuffer               = "lunchBuffer"
+	Conference_LunchRules                = "lunchRules"
+	Conference_Lunches                   = "lunches"
+	Conference_NoClassDescription        = "noClassDescription"
+	Conference_OABanquetDescription      = "oaBanquetDescription"
+	Conference_OABanquetLocation         = "oaBanquetLocation"
+	Conference_OpeningLocation           = "openingLocation"
+	Conference_RegistrationExportHeaders = "registrationExportHeaders"
+	Conference_RegistrationExportURL     = "registrationExportURL"
+	Conference_RegistrationURL           = "registrationURL"
+	Conference_Rooms                     = "rooms"
+	Conference_StaffIDs                  = "staffIDs"
+	Conference_TrainingCourses           = "trainingCourses"
 )
@@ -0,0 +1,13 @@
+// Code generated by gogen.go; DO NOT EDIT.
+
+package model
+
+const (
+	ImportRun_Count    = "count"
+	ImportRun_Duration = "duration"
+	ImportRun_Error    = "error"
+	ImportRun_Refused  = "refused"
+	ImportRun_Summary  = "summary"
+	ImportRun_Time     = "time"
+	ImportRun_Trigger  = "trigger"
+)
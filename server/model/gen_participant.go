@@ -9,7 +9,9 @@ import (
 
 const (
 	Participant_Address             = "address"
+	Participant_Allergies           = "allergies"
 	Participant_BSANumber           = "bsaNumber"
+	Participant_CheckinTime         = "checkinTime"
 	Participant_City                = "city"
 	Participant_Classes             = "classes"
 	Participant_Council             = "council"
@@ -17,17 +19,23 @@ const (
 	Participant_District            = "district"
 	Participant_Email               = "email"
 	Participant_FirstName           = "firstName"
+	Participant_FirstSeen           = "firstSeen"
 	Participant_ImportHash          = "importHash"
 	Participant_InstructorClasses   = "instructorClasses"
+	Participant_LastChanged         = "lastChanged"
 	Participant_LastName            = "lastName"
 	Participant_LoginCode           = "loginCode"
 	Participant_Marketing           = "marketing"
+	Participant_MergedIDs           = "mergedIDs"
+	Participant_MergedLoginCodes    = "mergedLoginCodes"
 	Participant_Nickname            = "nickname"
 	Participant_NoShow              = "noShow"
 	Participant_Notes               = "notes"
 	Participant_OABanquet           = "oaBanquet"
+	Participant_Overrides           = "overrides"
 	Participant_Phone               = "phone"
 	Participant_PrintForm           = "printForm"
+	Participant_Provisional         = "provisional"
 	Participant_RegisteredByEmail   = "regByEmail"
 	Participant_RegisteredByName    = "regByName"
 	Participant_RegisteredByPhone   = "regByPhone"
@@ -47,6 +55,7 @@ const (
 
 func (x *Participant) CopyImportFieldsTo(y *Participant) {
 	y.Address = x.Address
+	y.Allergies = x.Allergies
 	y.BSANumber = x.BSANumber
 	y.City = x.City
 	y.Classes = x.Classes
@@ -79,6 +88,7 @@ func (x *Participant) CopyImportFieldsTo(y *Participant) {
 
 func (x *Participant) EqualImportFields(y *Participant) bool {
 	return x.Address == y.Address &&
+		x.Allergies == y.Allergies &&
 		x.BSANumber == y.BSANumber &&
 		x.City == y.City &&
 		equalIntSlice(x.Classes, y.Classes) &&
@@ -111,8 +121,9 @@ func (x *Participant) EqualImportFields(y *Participant) bool {
 
 func (x *Participant) HashImportFields() string {
 	h := md5.New()
-	hashValue(h, "3a845a4ac1390a4620ce8f6c9e7cdfea")
+	hashValue(h, "3682cac68981012021de96cc55fde351")
 	hashValue(h, x.Address)
+	hashValue(h, x.Allergies)
 	hashValue(h, x.BSANumber)
 	hashValue(h, x.City)
 	hashValue(h, x.Classes)
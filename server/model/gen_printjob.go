@@ -0,0 +1,15 @@
+// Code generated by gogen.go; DO NOT EDIT.
+
+package model
+
+const (
+	PrintJob_Created        = "created"
+	PrintJob_Document       = "document"
+	PrintJob_Options        = "options"
+	PrintJob_ParticipantIDs = "participantIDs"
+	PrintJob_StaffID        = "staffID"
+	PrintJob_Station        = "station"
+	PrintJob_Status         = "status"
+	PrintJob_Template       = "template"
+	PrintJob_Updated        = "updated"
+)
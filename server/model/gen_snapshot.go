@@ -0,0 +1,14 @@
+// Code generated by gogen.go; DO NOT EDIT.
+
+package model
+
+const (
+	RegistrationSnapshot_Classes    = "classes"
+	RegistrationSnapshot_DaysBefore = "daysBefore"
+	RegistrationSnapshot_Programs   = "programs"
+	RegistrationSnapshot_Time       = "time"
+	RegistrationSnapshot_Total      = "total"
+	RegistrationSnapshot_Types      = "types"
+	RegistrationSnapshot_Units      = "units"
+	RegistrationSnapshot_Year       = "year"
+)
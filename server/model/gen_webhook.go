@@ -0,0 +1,24 @@
+// Code generated by gogen.go; DO NOT EDIT.
+
+package model
+
+const (
+	Webhook_Created = "created"
+	Webhook_Events  = "events"
+	Webhook_Secret  = "secret"
+	Webhook_URL     = "url"
+)
+
+const (
+	WebhookDelivery_Attempts    = "attempts"
+	WebhookDelivery_Body        = "body"
+	WebhookDelivery_Error       = "error"
+	WebhookDelivery_Event       = "event"
+	WebhookDelivery_EventID     = "eventID"
+	WebhookDelivery_NextAttempt = "nextAttempt"
+	WebhookDelivery_Pending     = "pending"
+	WebhookDelivery_Status      = "status"
+	WebhookDelivery_Time        = "time"
+	WebhookDelivery_URL         = "url"
+	WebhookDelivery_WebhookID   = "webhookID"
+)
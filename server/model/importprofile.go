@@ -0,0 +1,69 @@
+package model
+
+// ImportProfile maps the columns of a registration export from a system
+// other than Doubleknot to participant fields.
+type ImportProfile struct {
+	Name    string          `json:"name" datastore:"name,noindex"`
+	Columns []*ImportColumn `json:"columns" datastore:"columns,noindex"`
+	Classes ImportClasses   `json:"classes" datastore:"classes,noindex"`
+}
+
+// ImportColumn maps a column to a participant field.
+//
+// Field is the JSON name of a participant field (firstName, youth, ...) or
+// one of the following pseudo fields:
+//
+//	name  Full name. The last word is the last name and the remaining words
+//	      are the first name.
+//	type  Registration type. Sets youth and staff if the value contains
+//	      "Youth" or "Staff".
+//
+// Transforms are applied in order to the trimmed cell value:
+//
+//	title        Title case the value if it is all upper or all lower case.
+//	lower        Lower case.
+//	upper        Upper case.
+//	digits       The first run of digits with leading zeros removed.
+//	bool         "true" if the value is yes, y, true, x or 1, else "".
+//	bool=v       "true" if the value equals v ignoring case, else "".
+//	value=v      v if the value is not empty, else "".
+//	split=sep:n  Element n (counting from 0) of the value split on sep.
+//	prefix=p     Remove prefix p.
+//
+// When more than one column maps to a string field, the non-empty values are
+// joined with "; ". Map dietary checkbox columns to dietaryRestrictions with
+// the value=v transform to set dietary tag v. When more than one column maps
+// to a boolean field, the field is true if any of the values is true.
+type ImportColumn struct {
+	Column     string   `json:"column" datastore:"column,noindex"`
+	Field      string   `json:"field" datastore:"field,noindex"`
+	Transforms []string `json:"transforms,omitempty" datastore:"transforms,noindex"`
+
+	// Ignore a missing column.
+	Optional bool `json:"optional,omitempty" datastore:"optional,noindex"`
+}
+
+// Class selection encodings for ImportClasses.Mode.
+const (
+	// Columns contains the class numbers separated by Separator.
+	ImportClassesList = "list"
+
+	// Each column in Columns contains one class selection. Use for exports
+	// with one column per session.
+	ImportClassesColumns = "columns"
+
+	// One row per class. Rows with equal values in KeyColumns are merged
+	// into a single participant and the class is read from Columns. This is
+	// how Doubleknot encodes classes.
+	ImportClassesRows = "rows"
+)
+
+// ImportClasses specifies how class selections are encoded. Class numbers are
+// the leading three digits of a value, so "101: Cub Scout Basics" and "101"
+// both select class 101.
+type ImportClasses struct {
+	Mode       string   `json:"mode" datastore:"mode,noindex"`
+	Columns    []string `json:"columns" datastore:"columns,noindex"`
+	Separator  string   `json:"separator,omitempty" datastore:"separator,noindex,omitempty"`
+	KeyColumns []string `json:"keyColumns,omitempty" datastore:"keyColumns,noindex,omitempty"`
+}
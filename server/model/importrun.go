@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+//go:generate go run gogen.go -input importrun.go -output gen_importrun.go ImportRun
+
+// Sources of registration import runs.
+const (
+	ImportTriggerCron   = "cron"
+	ImportTriggerTicker = "ticker"
+	ImportTriggerManual = "manual"
+)
+
+// ImportRun records a fetch and import of the Doubleknot registration
+// export.
+type ImportRun struct {
+	Time     time.Time     `json:"time" datastore:"time"`
+	Trigger  string        `json:"trigger" datastore:"trigger,noindex"`
+	Duration time.Duration `json:"duration" datastore:"duration,noindex"`
+
+	// Number of records in the export.
+	Count int `json:"count" datastore:"count,noindex"`
+
+	Summary string `json:"summary" datastore:"summary,noindex,omitempty"`
+	Error   string `json:"error" datastore:"error,noindex,omitempty"`
+
+	// Refused is true if the import was not applied because the export
+	// looked truncated. Error describes the reason.
+	Refused bool `json:"refused" datastore:"refused,noindex,omitempty"`
+}
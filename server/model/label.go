@@ -0,0 +1,114 @@
+package model
+
+import "fmt"
+
+// LabelTemplate describes a sheet of labels. Dimensions are in inches.
+type LabelTemplate struct {
+	Name string `json:"name" datastore:"name,noindex"`
+
+	// Page size. Letter is used when zero.
+	PageWidth  float64 `json:"pageWidth,omitempty" datastore:"pageWidth,noindex,omitempty"`
+	PageHeight float64 `json:"pageHeight,omitempty" datastore:"pageHeight,noindex,omitempty"`
+
+	Rows    int `json:"rows" datastore:"rows,noindex"`
+	Columns int `json:"columns" datastore:"columns,noindex"`
+
+	// Position of the top left label relative to the top left corner of
+	// the page.
+	Top  float64 `json:"top" datastore:"top,noindex"`
+	Left float64 `json:"left" datastore:"left,noindex"`
+
+	// Label size.
+	Width  float64 `json:"width" datastore:"width,noindex"`
+	Height float64 `json:"height" datastore:"height,noindex"`
+
+	// Space between columns and rows.
+	ColumnGap float64 `json:"columnGap,omitempty" datastore:"columnGap,noindex,omitempty"`
+	RowGap    float64 `json:"rowGap,omitempty" datastore:"rowGap,noindex,omitempty"`
+
+	// Font size in points for the main line of text. Other text is sized
+	// relative to this value.
+	FontSize float64 `json:"fontSize,omitempty" datastore:"fontSize,noindex,omitempty"`
+}
+
+// Page returns the page size in inches.
+func (t *LabelTemplate) Page() (width, height float64) {
+	width, height = t.PageWidth, t.PageHeight
+	if width == 0 {
+		width = 8.5
+	}
+	if height == 0 {
+		height = 11
+	}
+	return width, height
+}
+
+// PerPage returns the number of labels on a sheet.
+func (t *LabelTemplate) PerPage() int {
+	return t.Rows * t.Columns
+}
+
+// Check returns an error if the labels do not fit on the page.
+func (t *LabelTemplate) Check() error {
+	if t.Rows <= 0 || t.Columns <= 0 || t.Width <= 0 || t.Height <= 0 {
+		return fmt.Errorf("label template %q: rows, columns, width and height must be positive", t.Name)
+	}
+	pw, ph := t.Page()
+	if w := t.Left + float64(t.Columns)*t.Width + float64(t.Columns-1)*t.ColumnGap; w > pw+0.001 {
+		return fmt.Errorf("label template %q: labels are %.3gin wide, page is %.3gin", t.Name, w, pw)
+	}
+	if h := t.Top + float64(t.Rows)*t.Height + float64(t.Rows-1)*t.RowGap; h > ph+0.001 {
+		return fmt.Errorf("label template %q: labels are %.3gin high, page is %.3gin", t.Name, h, ph)
+	}
+	return nil
+}
+
+// DefaultLabelTemplates are available in addition to the templates in
+// Conference.LabelTemplates. A conference template with the same name
+// replaces the default.
+var DefaultLabelTemplates = []*LabelTemplate{
+	// Badge sticker on the front of the participant form.
+	{Name: "form", Rows: 1, Columns: 1, Top: 0.875, Left: 0.275, Width: 4, Height: 2.5, FontSize: 26},
+	// Lunch stickers as printed in previous years.
+	{Name: "lunch", Rows: 7, Columns: 2, Top: 0.8, Width: 4.25, Height: 1.325, FontSize: 16},
+	// 1" x 2-5/8" address labels, 30 per sheet.
+	{Name: "avery5160", Rows: 10, Columns: 3, Top: 0.5, Left: 0.1875, Width: 2.625, Height: 1, ColumnGap: 0.125, FontSize: 11},
+	// 2" x 4" shipping labels, 10 per sheet.
+	{Name: "avery5163", Rows: 5, Columns: 2, Top: 0.5, Left: 0.156, Width: 4, Height: 2, ColumnGap: 0.188, FontSize: 16},
+	// 3" x 4" name badges, 6 per sheet.
+	{Name: "avery5392", Rows: 3, Columns: 2, Top: 1, Left: 0.25, Width: 4, Height: 3, FontSize: 26},
+	// 2-1/3" x 3-3/8" name badges, 8 per sheet.
+	{Name: "avery5395", Rows: 4, Columns: 2, Top: 0.594, Left: 0.688, Width: 3.375, Height: 2.333, ColumnGap: 0.375, RowGap: 0.083, FontSize: 22},
+}
+
+// LabelTemplate returns the label template with the given name or nil if
+// the template is not found.
+func (c *Conference) LabelTemplate(name string) *LabelTemplate {
+	for _, t := range c.LabelTemplates {
+		if t.Name == name {
+			return t
+		}
+	}
+	for _, t := range DefaultLabelTemplates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// LabelTemplateNames returns the names of the conference and default label
+// templates.
+func (c *Conference) LabelTemplateNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, ts := range [][]*LabelTemplate{c.LabelTemplates, DefaultLabelTemplates} {
+		for _, t := range ts {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				names = append(names, t.Name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,355 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LunchRule assigns participants to a lunch. A rule matches a participant
+// when all of the rule's non-empty selectors match. Rules are checked in
+// priority order, highest first, before the class and unit type selectors
+// on each lunch.
+type LunchRule struct {
+	// Name of the lunch assigned by the rule.
+	Lunch string `json:"lunch" datastore:"lunch,noindex"`
+
+	// Rules with higher priority are checked first. Rules with the same
+	// priority are checked in the order listed.
+	Priority int `json:"priority" datastore:"priority,noindex"`
+
+	// Participant IDs for explicit overrides.
+	Participants []string `json:"participants,omitempty" datastore:"participants,noindex"`
+
+	// Staff roles from registration. The role "*" matches all staff.
+	StaffRoles []string `json:"staffRoles,omitempty" datastore:"staffRoles,noindex"`
+
+	// "youth" or "adult".
+	Age string `json:"age,omitempty" datastore:"age,noindex,omitempty"`
+
+	// Matches participants with any of these dietary tags. The tag
+	// AllergyNotesTag matches participants with allergy notes.
+	Dietary []string `json:"dietary,omitempty" datastore:"dietary,noindex"`
+
+	Councils []string `json:"councils,omitempty" datastore:"councils,noindex"`
+
+	// Set on rules created by the seating assignment step. These rules are
+	// replaced each time the step runs. Unlike other rules, these rules
+	// are checked after the lunches assigned by class.
+	Balance bool `json:"balance,omitempty" datastore:"balance,noindex,omitempty"`
+}
+
+// Unit types from registration used in Lunch.UnitTypes.
+var lunchUnitTypes = []string{"Pack", "Troop", "Crew", "Ship"}
+
+// Lunch rule ages.
+const (
+	LunchRuleYouth = "youth"
+	LunchRuleAdult = "adult"
+)
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the rule applies to the participant.
+func (r *LunchRule) Matches(p *Participant) bool {
+	if len(r.Participants) > 0 && !containsFold(r.Participants, p.ID) {
+		return false
+	}
+	if len(r.StaffRoles) > 0 {
+		if !p.Staff && p.StaffRole == "" {
+			return false
+		}
+		if !containsFold(r.StaffRoles, "*") && !containsFold(r.StaffRoles, p.StaffRole) {
+			return false
+		}
+	}
+	switch r.Age {
+	case LunchRuleYouth:
+		if !p.Youth {
+			return false
+		}
+	case LunchRuleAdult:
+		if p.Youth {
+			return false
+		}
+	}
+	if len(r.Dietary) > 0 {
+		found := false
+		for _, d := range r.Dietary {
+			if p.HasDietaryTag(d) || (strings.EqualFold(d, AllergyNotesTag) && p.Allergies != "") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.Councils) > 0 && !containsFold(r.Councils, p.Council) {
+		return false
+	}
+	return true
+}
+
+// String returns a short description of the rule.
+func (r *LunchRule) String() string {
+	var parts []string
+	if len(r.Participants) > 0 {
+		if len(r.Participants) <= 3 {
+			parts = append(parts, "participants "+strings.Join(r.Participants, ", "))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d participants", len(r.Participants)))
+		}
+	}
+	if len(r.StaffRoles) > 0 {
+		parts = append(parts, "staff "+strings.Join(r.StaffRoles, ", "))
+	}
+	if r.Age != "" {
+		parts = append(parts, r.Age)
+	}
+	if len(r.Dietary) > 0 {
+		parts = append(parts, "dietary "+strings.Join(r.Dietary, ", "))
+	}
+	if len(r.Councils) > 0 {
+		parts = append(parts, "council "+strings.Join(r.Councils, ", "))
+	}
+	if r.Balance {
+		parts = append(parts, "seating balance")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "everyone")
+	}
+	return strings.Join(parts, "; ") + " → " + r.Lunch
+}
+
+// sortedLunchRules returns the rules in the order checked.
+func sortedLunchRules(rules []*LunchRule) []*LunchRule {
+	sorted := append([]*LunchRule(nil), rules...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority > sorted[j].Priority })
+	return sorted
+}
+
+// Lunch returns the lunch with the given name or nil if not found.
+func (c *Conference) Lunch(name string) *Lunch {
+	for _, l := range c.Lunches {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// CheckLunches returns a list of problems with the lunch configuration.
+// Classes is used to check class numbers. Classes are not checked if nil.
+func CheckLunches(lunches []*Lunch, rules []*LunchRule, classes []*Class) []string {
+	var problems []string
+	names := make(map[string]bool)
+	classNumbers := make(map[int]bool)
+	for _, c := range classes {
+		classNumbers[c.Number] = true
+	}
+	classLunch := make(map[int]string)
+	for _, l := range lunches {
+		switch {
+		case l.Name == "":
+			problems = append(problems, "Lunch name is required.")
+		case names[l.Name]:
+			problems = append(problems, fmt.Sprintf("Lunch %q is listed more than once.", l.Name))
+		}
+		names[l.Name] = true
+		if l.Seating != 1 && l.Seating != 2 {
+			problems = append(problems, fmt.Sprintf("Lunch %q: seating must be 1 or 2.", l.Name))
+		}
+		if l.Capacity < 0 {
+			problems = append(problems, fmt.Sprintf("Lunch %q: capacity must not be negative.", l.Name))
+		}
+		for _, n := range l.Classes {
+			if classes != nil && !classNumbers[n] {
+				problems = append(problems, fmt.Sprintf("Lunch %q: class %d not found.", l.Name, n))
+			}
+			if other, ok := classLunch[n]; ok {
+				problems = append(problems, fmt.Sprintf("Lunch %q: class %d is also assigned to %q.", l.Name, n, other))
+			}
+			classLunch[n] = l.Name
+		}
+		for _, t := range l.UnitTypes {
+			found := false
+			for _, ut := range lunchUnitTypes {
+				found = found || ut == t
+			}
+			if !found {
+				problems = append(problems, fmt.Sprintf("Lunch %q: unknown unit type %q, use one of %s.", l.Name, t, strings.Join(lunchUnitTypes, ", ")))
+			}
+		}
+	}
+	for i, r := range rules {
+		if !names[r.Lunch] {
+			problems = append(problems, fmt.Sprintf("Rule %d: lunch %q not found.", i+1, r.Lunch))
+		}
+		if r.Age != "" && r.Age != LunchRuleYouth && r.Age != LunchRuleAdult {
+			problems = append(problems, fmt.Sprintf("Rule %d: age must be %q or %q.", i+1, LunchRuleYouth, LunchRuleAdult))
+		}
+	}
+	return problems
+}
+
+// LunchSeating is the projected use of a lunch location at one seating.
+type LunchSeating struct {
+	Location string
+	Seating  int
+	Lunches  []*Lunch
+	Capacity int
+	Count    int
+}
+
+// Overflow returns the number of participants over capacity.
+func (s *LunchSeating) Overflow() int {
+	if s.Capacity == 0 || s.Count <= s.Capacity {
+		return 0
+	}
+	return s.Count - s.Capacity
+}
+
+// Utilization returns the count as a percentage of capacity or zero if the
+// capacity is not known.
+func (s *LunchSeating) Utilization() int {
+	if s.Capacity == 0 {
+		return 0
+	}
+	return s.Count * 100 / s.Capacity
+}
+
+// LunchSeatings returns the projected use of each lunch location and
+// seating. The seatings are returned in lunch order.
+func (c *Conference) LunchSeatings(participants []*Participant) []*LunchSeating {
+	seatings, byLunch := c.lunchSeatings()
+	for _, p := range participants {
+		if s := byLunch[c.ParticipantLunch(p)]; s != nil {
+			s.Count++
+		}
+	}
+	return seatings
+}
+
+func (c *Conference) lunchSeatings() ([]*LunchSeating, map[*Lunch]*LunchSeating) {
+	type key struct {
+		location string
+		seating  int
+	}
+	var seatings []*LunchSeating
+	byKey := make(map[key]*LunchSeating)
+	byLunch := make(map[*Lunch]*LunchSeating)
+	for _, l := range c.Lunches {
+		k := key{l.Location, l.Seating}
+		s := byKey[k]
+		if s == nil {
+			s = &LunchSeating{Location: l.Location, Seating: l.Seating}
+			byKey[k] = s
+			seatings = append(seatings, s)
+		}
+		s.Lunches = append(s.Lunches, l)
+		s.Capacity += l.Capacity
+		byLunch[l] = s
+	}
+	return seatings, byLunch
+}
+
+// BalanceLunchSeatings returns lunch rules that move participants from
+// seatings over capacity to another seating at the same location with room
+// to spare. The returned rules replace the previous seating assignment
+// rules. The number of participants moved is also returned.
+//
+// A participant is moved only when the participant's lunch is not set by a
+// rule and the participant does not attend or teach a class during the lunch
+// session. The seating for these participants is not constrained by the
+// class schedule. Participants are moved in unit order so that units tend to
+// stay together.
+func (c *Conference) BalanceLunchSeatings(participants []*Participant, classInfo *ClassInfo) ([]*LunchRule, int) {
+	var rules []*LunchRule
+	priority := 0
+	for _, r := range c.LunchRules {
+		if r.Balance {
+			continue
+		}
+		if len(rules) == 0 || r.Priority <= priority {
+			priority = r.Priority - 1
+		}
+		rules = append(rules, r)
+	}
+
+	// Compute the assignment without the previous balance rules.
+	base := &Conference{Lunches: c.Lunches, LunchRules: rules}
+	seatings, byLunch := base.lunchSeatings()
+	lunchCount := make(map[*Lunch]int)
+	participantLunch := make(map[*Participant]*Lunch)
+	movable := make(map[*LunchSeating][]*Participant)
+
+	participants = append([]*Participant(nil), participants...)
+	SortParticipants(participants, "unit")
+	for _, p := range participants {
+		l := base.ParticipantLunch(p)
+		s := byLunch[l]
+		if s == nil {
+			continue
+		}
+		s.Count++
+		lunchCount[l]++
+		participantLunch[p] = l
+		if base.ParticipantLunchRule(p) != nil {
+			continue
+		}
+		if n := classInfo.ParticipantSessionClasses(p)[LunchSession].Number; n != 0 && n != NoClassClassNumber {
+			continue
+		}
+		movable[s] = append(movable[s], p)
+	}
+
+	moves := make(map[*Lunch][]string)
+	var targets []*Lunch
+	moved := 0
+	for _, from := range seatings {
+		for _, to := range seatings {
+			if from.Overflow() == 0 {
+				break
+			}
+			if to == from || to.Location != from.Location || to.Capacity == 0 {
+				continue
+			}
+			for from.Overflow() > 0 && to.Count < to.Capacity && len(movable[from]) > 0 {
+				// Pick the lunch at the seating with the most room.
+				var l *Lunch
+				for _, tl := range to.Lunches {
+					if l == nil || tl.Capacity-lunchCount[tl] > l.Capacity-lunchCount[l] {
+						l = tl
+					}
+				}
+				ps := movable[from]
+				p := ps[len(ps)-1]
+				movable[from] = ps[:len(ps)-1]
+				from.Count--
+				to.Count++
+				lunchCount[participantLunch[p]]--
+				lunchCount[l]++
+				if moves[l] == nil {
+					targets = append(targets, l)
+				}
+				moves[l] = append(moves[l], p.ID)
+				moved++
+			}
+		}
+	}
+
+	for _, l := range targets {
+		ids := moves[l]
+		sort.Strings(ids)
+		rules = append(rules, &LunchRule{Lunch: l.Name, Priority: priority, Participants: ids, Balance: true})
+	}
+	return rules, moved
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// ParticipantOverride is a participant edit to a registration field. The
+// override takes precedence over the value from registration and is kept
+// when participants are imported.
+type ParticipantOverride struct {
+	// JSON name of the field.
+	Field string `json:"field" datastore:"field,noindex"`
+
+	Value string `json:"value" datastore:"value,noindex"`
+
+	// Registration value when the participant made the edit.
+	Base string `json:"base" datastore:"base,noindex"`
+
+	// Registration value from the most recent import.
+	Imported string `json:"imported" datastore:"imported,noindex"`
+
+	Updated time.Time `json:"updated" datastore:"updated,noindex"`
+}
+
+// Conflict returns true if registration changed the field to a different
+// value after the participant made the edit.
+func (o *ParticipantOverride) Conflict() bool {
+	return o.Imported != o.Base && o.Imported != o.Value
+}
+
+type overrideField struct {
+	label string
+	get   func(p *Participant) string
+	set   func(p *Participant, s string)
+
+	// Remove the override when an import brings in the override value.
+	confirm bool
+}
+
+var overrideFields = map[string]*overrideField{
+	"nickname": {
+		"Nickname",
+		func(p *Participant) string { return p.Nickname },
+		func(p *Participant, s string) { p.Nickname = s },
+		false,
+	},
+	"dietaryRestrictions": {
+		"Dietary restrictions",
+		func(p *Participant) string { return p.DietaryRestrictions },
+		func(p *Participant, s string) { p.DietaryRestrictions = s },
+		false,
+	},
+	"allergies": {
+		"Allergies",
+		func(p *Participant) string { return p.Allergies },
+		func(p *Participant, s string) { p.Allergies = s },
+		false,
+	},
+	"showQRCode": {
+		"Show QR code",
+		func(p *Participant) string { return strconv.FormatBool(p.ShowQRCode) },
+		func(p *Participant, s string) { p.ShowQRCode, _ = strconv.ParseBool(s) },
+		false,
+	},
+	"phone": {
+		"Phone",
+		func(p *Participant) string { return p.Phone },
+		func(p *Participant, s string) { p.Phone = s },
+		false,
+	},
+	"classes": {
+		"Classes",
+		func(p *Participant) string { return formatClasses(p.Classes) },
+		func(p *Participant, s string) { p.Classes = parseClasses(s) },
+		true,
+	},
+}
+
+// Label returns a label for the overridden field.
+func (o *ParticipantOverride) Label() string {
+	if f := overrideFields[o.Field]; f != nil {
+		return f.label
+	}
+	return o.Field
+}
+
+// Override returns the participant's override for field or nil if the field
+// is not overridden.
+func (p *Participant) Override(field string) *ParticipantOverride {
+	for i := range p.Overrides {
+		if p.Overrides[i].Field == field {
+			return &p.Overrides[i]
+		}
+	}
+	return nil
+}
+
+// HasOverrideConflict returns true if any of the participant's overrides
+// conflict with registration.
+func (p *Participant) HasOverrideConflict() bool {
+	for i := range p.Overrides {
+		if p.Overrides[i].Conflict() {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOverride sets field to value. The override is removed if value is
+// equal to the registration value. SetOverride returns true if the field
+// value changed. SetOverride panics if field is not one of nickname,
+// dietaryRestrictions, allergies, showQRCode, phone or classes. The value of
+// classes is a comma separated list of class numbers.
+func (p *Participant) SetOverride(field string, value string, now time.Time) bool {
+	f := overrideFields[field]
+	if f == nil {
+		panic("model: unknown override field " + field)
+	}
+	current := f.get(p)
+	o := p.Override(field)
+	imported := current
+	if o != nil {
+		imported = o.Imported
+	}
+	if value == imported {
+		p.RemoveOverride(field)
+	} else if o != nil {
+		if o.Value == value {
+			return false
+		}
+		o.Value = value
+		o.Base = imported
+		o.Updated = now
+	} else {
+		p.Overrides = append(p.Overrides, ParticipantOverride{
+			Field:    field,
+			Value:    value,
+			Base:     imported,
+			Imported: imported,
+			Updated:  now,
+		})
+	}
+	f.set(p, value)
+	return value != current
+}
+
+// RemoveOverride removes the override for field and restores the value from
+// registration.
+func (p *Participant) RemoveOverride(field string) {
+	for i := range p.Overrides {
+		o := p.Overrides[i]
+		if o.Field == field {
+			if f := overrideFields[field]; f != nil {
+				f.set(p, o.Imported)
+			}
+			p.Overrides = append(p.Overrides[:i], p.Overrides[i+1:]...)
+			return
+		}
+	}
+}
+
+// KeepOverride resolves a conflict by keeping the participant's value.
+func (p *Participant) KeepOverride(field string) {
+	if o := p.Override(field); o != nil {
+		o.Base = o.Imported
+	}
+}
+
+// MergeImport copies the import fields from the imported participant to p
+// and then applies p's overrides. The registration values of the overridden
+// fields are recorded in the overrides. Class overrides are removed when
+// registration confirms the change. MergeImport returns true if a print
+// field changed.
+func (p *Participant) MergeImport(imported *Participant) bool {
+	x := *imported
+	overrides := p.Overrides[:0]
+	for _, o := range p.Overrides {
+		f := overrideFields[o.Field]
+		if f == nil {
+			overrides = append(overrides, o)
+			continue
+		}
+		o.Imported = f.get(&x)
+		if f.confirm && o.Imported == o.Value {
+			continue
+		}
+		f.set(&x, o.Value)
+		overrides = append(overrides, o)
+	}
+	p.Overrides = overrides
+	changed := !x.EqualPrintFields(p)
+	x.CopyImportFieldsTo(p)
+	return changed
+}
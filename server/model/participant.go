@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/datastore"
 )
@@ -39,7 +40,8 @@ type Participant struct {
 	District            string `json:"district" datastore:"district" fields:"Import"`
 	UnitType            string `json:"unitType" datastore:"unitType" fields:"Import"`
 	UnitNumber          string `json:"unitNumber" datastore:"unitNumber" fields:"Import"`
-	DietaryRestrictions string `json:"dietaryRestrictions" datastore:"dietaryRestrictions" fields:"Import"`
+	DietaryRestrictions string `json:"dietaryRestrictions" datastore:"dietaryRestrictions" fields:"Import"` // dietary tags separated by "; "
+	Allergies           string `json:"allergies" datastore:"allergies" fields:"Import"`
 	Marketing           string `json:"marketing" datastore:"marketing,noindex,omitempty" fields:"Import"`
 	ScoutingYears       string `json:"scoutingYears" datastore:"scoutingYears,noindex,omitempty" fields:"Import"`
 	ShowQRCode          bool   `json:"showQRCode" datastore:"showQRCode,noindex,omitempty" fields:"Import"`
@@ -49,8 +51,21 @@ type Participant struct {
 	OABanquet           bool   `json:"oaBanquet" datastore:"oaBanquet" fields:"Import,Print"`
 
 	InstructorClasses []InstructorClass `json:"instructorClasses" datastore:"instructorClasses,omitempty" fields:"Print"`
-	Notes             string            `json:"notes" datastore:"notes,noindex,omitempty" fields:""`
-	NoShow            bool              `json:"noShow" datastore:"noShow,noindex,omitempty" fields:""`
+
+	// Edits made by the participant. The edits are applied to the fields above
+	// and are kept when registrations are imported.
+	Overrides []ParticipantOverride `json:"overrides" datastore:"overrides,noindex,omitempty" fields:""`
+
+	Notes  string `json:"notes" datastore:"notes,noindex,omitempty" fields:""`
+	NoShow bool   `json:"noShow" datastore:"noShow,noindex,omitempty" fields:""`
+
+	// Time that staff checked in the participant at the conference.
+	CheckinTime time.Time `json:"checkinTime" datastore:"checkinTime,noindex" fields:""`
+
+	// Times that the participant was first and last changed by a
+	// registration import.
+	FirstSeen   time.Time `json:"firstSeen" datastore:"firstSeen,noindex,omitempty" fields:""`
+	LastChanged time.Time `json:"lastChanged" datastore:"lastChanged,noindex,omitempty" fields:""`
 
 	// Hash computed from Doubleknot registration fields.
 	ImportHash string `json:"importHash" datastore:"importHash"`
@@ -61,6 +76,17 @@ type Participant struct {
 	// Unique seven digit code assigned during import.
 	LoginCode string `json:"loginCode" datastore:"loginCode"`
 
+	// Set for walk-in participants added by staff at the conference. The
+	// record is replaced by the registration record when an import brings
+	// in a participant with the same name and email.
+	Provisional bool `json:"provisional" datastore:"provisional,omitempty"`
+
+	// IDs and login codes of duplicate participants merged into this
+	// participant. Imports of a merged registration update this participant
+	// and the merged login codes can be used to log in.
+	MergedIDs        []string `json:"mergedIDs" datastore:"mergedIDs,omitempty"`
+	MergedLoginCodes []string `json:"mergedLoginCodes" datastore:"mergedLoginCodes,omitempty"`
+
 	sortName string
 }
 
@@ -76,6 +102,25 @@ func (p *Participant) Type() string {
 	}
 }
 
+// Program returns the program of the participant's unit or nil if the unit
+// type is not known. Youth participants are in the youth program.
+func (p *Participant) Program() *ProgramDescription {
+	if p.Youth {
+		return ProgramDescriptions[YouthProgram]
+	}
+	switch strings.ToLower(p.UnitType) {
+	case "pack":
+		return ProgramDescriptions[CubScoutProgram]
+	case "troop":
+		return ProgramDescriptions[ScoutsBSAProgram]
+	case "crew":
+		return ProgramDescriptions[VenturingProgram]
+	case "ship":
+		return ProgramDescriptions[SeaScoutProgram]
+	}
+	return nil
+}
+
 func (p *Participant) Unit() string {
 	if p.UnitNumber == "" {
 		return p.UnitType
@@ -113,6 +158,23 @@ func (p *Participant) Emails() []string {
 	return []string{p.RegisteredByEmail, p.Email}
 }
 
+// SameNameAndEmail returns true if the participants have the same first
+// and last name and share an email address.
+func (p *Participant) SameNameAndEmail(q *Participant) bool {
+	if !strings.EqualFold(strings.TrimSpace(p.FirstName), strings.TrimSpace(q.FirstName)) ||
+		!strings.EqualFold(strings.TrimSpace(p.LastName), strings.TrimSpace(q.LastName)) {
+		return false
+	}
+	for _, a := range p.Emails() {
+		for _, b := range q.Emails() {
+			if a != "" && strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // init initializes derived fields.
 func (p *Participant) init() {
 	p.sortName = strings.ToLower(fmt.Sprintf("%s\n%s\n%s", p.LastName, p.FirstName, p.Suffix))
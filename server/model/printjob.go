@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+//go:generate go run gogen.go -input printjob.go -output gen_printjob.go PrintJob
+
+// Print job statuses.
+const (
+	// The job was sent to the print station and the station has not
+	// reported that printing is complete.
+	PrintJobPrinting = "printing"
+
+	// The station reported that printing is complete.
+	PrintJobPrinted = "printed"
+
+	// Staff reported that the job did not print.
+	PrintJobFailed = "failed"
+
+	// The participants in the job were added back to the print queue.
+	PrintJobRequeued = "requeued"
+)
+
+// Print job documents.
+const (
+	PrintJobForms  = "forms"
+	PrintJobBadges = "badges"
+)
+
+// PrintJob is a batch of participant forms or badges taken from the print
+// queue.
+type PrintJob struct {
+	ID      int64     `json:"id" datastore:"-"`
+	Created time.Time `json:"created" datastore:"created"`
+	Updated time.Time `json:"updated" datastore:"updated,noindex"`
+
+	// Name of the print station, typically the printer or computer name.
+	Station string `json:"station" datastore:"station,noindex"`
+
+	// ID of the staff member who requested the job.
+	StaffID string `json:"staffID" datastore:"staffID,noindex"`
+
+	// Name of the forms page options used to select the batch.
+	Options string `json:"options" datastore:"options,noindex"`
+
+	// The document printed, forms if empty.
+	Document string `json:"document" datastore:"document,noindex,omitempty"`
+
+	// Label template for badges.
+	Template string `json:"template" datastore:"template,noindex,omitempty"`
+
+	ParticipantIDs []string `json:"participantIDs" datastore:"participantIDs,noindex"`
+
+	Status string `json:"status" datastore:"status,noindex"`
+}
+
+// InFlight returns true if the job is not known to be complete.
+func (j *PrintJob) InFlight() bool {
+	return j.Status == PrintJobPrinting
+}
+
+// IsBadges returns true if the job prints badges.
+func (j *PrintJob) IsBadges() bool {
+	return j.Document == PrintJobBadges
+}
+
+// SameBatch returns true if the job is for the same document and
+// participants at the same station.
+func (j *PrintJob) SameBatch(station string, document string, participantIDs []string) bool {
+	if j.Station != station || j.Document != document || len(j.ParticipantIDs) != len(participantIDs) {
+		return false
+	}
+	for i, id := range participantIDs {
+		if j.ParticipantIDs[i] != id {
+			return false
+		}
+	}
+	return true
+}
+
+func (j *PrintJob) Load(ps []datastore.Property) error {
+	return datastore.LoadStruct(j, ps)
+}
+
+func (j *PrintJob) LoadKey(k *datastore.Key) error {
+	j.ID = k.ID
+	return nil
+}
+
+func (j *PrintJob) Save() ([]datastore.Property, error) {
+	return datastore.SaveStruct(j)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Room is a room available for classes.
+type Room struct {
+	Name     string `json:"name" datastore:"name,noindex"`
+	Capacity int    `json:"capacity" datastore:"capacity,noindex"`
+
+	// Features are the equipment and other attributes of the room such as
+	// projector, sink or outdoor. Classes list the features they need in
+	// Class.RoomFeatures.
+	Features []string `json:"features" datastore:"features,noindex"`
+}
+
+// HasFeatures returns true if the room has all of the given features.
+// Features are compared without regard to case.
+func (r *Room) HasFeatures(features []string) bool {
+	for _, f := range features {
+		found := false
+		for _, rf := range r.Features {
+			if strings.EqualFold(f, rf) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckRooms returns an error if a room does not have a name, if a name is
+// used more than once or if a capacity is not positive.
+func CheckRooms(rooms []*Room) error {
+	names := make(map[string]bool)
+	for _, r := range rooms {
+		name := strings.ToLower(strings.TrimSpace(r.Name))
+		switch {
+		case name == "":
+			return fmt.Errorf("room name is required")
+		case names[name]:
+			return fmt.Errorf("room %q is listed more than once", r.Name)
+		case r.Capacity <= 0:
+			return fmt.Errorf("room %q: capacity must be greater than zero", r.Name)
+		}
+		names[name] = true
+	}
+	return nil
+}
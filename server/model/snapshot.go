@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+//go:generate go run gogen.go -input snapshot.go -output gen_snapshot.go RegistrationSnapshot
+
+// RegistrationCount is the number of registrations in a category.
+type RegistrationCount struct {
+	Name  string `json:"name" datastore:"name,noindex"`
+	Count int    `json:"count" datastore:"count,noindex"`
+}
+
+// UnitCount is the number of registrations from a unit.
+type UnitCount struct {
+	Council  string `json:"council" datastore:"council,noindex"`
+	District string `json:"district" datastore:"district,noindex"`
+
+	// Unit type and number, e.g. Pack 123.
+	Unit  string `json:"unit" datastore:"unit,noindex"`
+	Count int    `json:"count" datastore:"count,noindex"`
+}
+
+// RegistrationSnapshot records the registration counts at the end of a day.
+// Snapshots are kept from year to year for comparison.
+type RegistrationSnapshot struct {
+	// Date in the conference time zone, formatted as 2006-01-02.
+	Date string `json:"date" datastore:"-"`
+
+	// Conference year and days before the conference.
+	Year       int `json:"year" datastore:"year,noindex"`
+	DaysBefore int `json:"daysBefore" datastore:"daysBefore,noindex"`
+
+	Time  time.Time `json:"time" datastore:"time,noindex"`
+	Total int       `json:"total" datastore:"total,noindex"`
+
+	Types    []RegistrationCount `json:"types" datastore:"types,noindex"`
+	Programs []RegistrationCount `json:"programs" datastore:"programs,noindex"`
+
+	// Registrations by class. The name is the class number.
+	Classes []RegistrationCount `json:"classes" datastore:"classes,noindex"`
+
+	Units []UnitCount `json:"units" datastore:"units,noindex"`
+}
+
+func (s *RegistrationSnapshot) Load(ps []datastore.Property) error {
+	return datastore.LoadStruct(s, ps)
+}
+
+func (s *RegistrationSnapshot) LoadKey(k *datastore.Key) error {
+	s.Date = k.Name
+	return nil
+}
+
+func (s *RegistrationSnapshot) Save() ([]datastore.Property, error) {
+	return datastore.SaveStruct(s)
+}
@@ -1,5 +1,7 @@
 package model
 
+import "fmt"
+
 type SSClass struct {
 	Number   int  `json:"number"`
 	Elective bool `json:"elective"`
@@ -10,3 +12,57 @@ type SuggestedSchedule struct {
 	Name    string    `json:"name"`
 	Classes []SSClass `json:"classes"`
 }
+
+// offeredFor returns true if the class is offered for the program. The
+// program is one of the xxxProgram constants.
+func (c *Class) offeredFor(program int) bool {
+	return c.Programs&(1<<uint(program)) != 0
+}
+
+// ScheduleAlternatives returns the classes offered for the program that meet
+// in the same sessions as class c. The result includes c.
+func (ci *ClassInfo) ScheduleAlternatives(c *Class, program int) []*Class {
+	start, end := c.StartEnd()
+	var result []*Class
+	for _, x := range ci.classes {
+		xstart, xend := x.StartEnd()
+		if x == c || (xstart == start && xend == end && x.offeredFor(program)) {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+// ScheduleOverlaps returns a description of each pair of classes in the
+// schedule that meet in the same session.
+func (ci *ClassInfo) ScheduleOverlaps(numbers []int) []string {
+	var classes []*Class
+	for _, n := range numbers {
+		if c := ci.LookupNumber(n); c != nil {
+			classes = append(classes, c)
+		}
+	}
+	var result []string
+	for i, a := range classes {
+		astart, aend := a.StartEnd()
+		for _, b := range classes[i+1:] {
+			bstart, bend := b.StartEnd()
+			if astart > bend || bstart > aend {
+				continue
+			}
+			start, end := astart, aend
+			if bstart > start {
+				start = bstart
+			}
+			if bend < end {
+				end = bend
+			}
+			if start == end {
+				result = append(result, fmt.Sprintf("Class %d overlaps class %d in session %d.", a.Number, b.Number, start+1))
+			} else {
+				result = append(result, fmt.Sprintf("Class %d overlaps class %d in sessions %d to %d.", a.Number, b.Number, start+1, end+1))
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package model
+
+import "regexp"
+
+// TrainingCourse maps a class to a course code in the national training
+// system.
+type TrainingCourse struct {
+	Class int    `json:"class" datastore:"class,noindex"`
+	Code  string `json:"code" datastore:"code,noindex"`
+}
+
+// TrainingCourseCode returns the course code for the class or "" if the
+// class is not a training course.
+func (c *Conference) TrainingCourseCode(number int) string {
+	for _, tc := range c.TrainingCourses {
+		if tc.Class == number {
+			return tc.Code
+		}
+	}
+	return ""
+}
+
+var bsaNumberPattern = regexp.MustCompile(`^[0-9]{6,12}$`)
+
+// BSANumberProblem returns a description of the problem with the
+// participant's BSA member ID or "" if the ID looks valid.
+func (p *Participant) BSANumberProblem() string {
+	switch {
+	case p.BSANumber == "":
+		return "missing BSA number"
+	case !bsaNumberPattern.MatchString(p.BSANumber):
+		return "malformed BSA number"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+//go:generate go run gogen.go -input webhook.go -output gen_webhook.go Webhook WebhookDelivery
+
+// Webhook event types.
+const (
+	ParticipantAddedEvent    = "participant.added"
+	ParticipantUpdatedEvent  = "participant.updated"
+	ParticipantDeletedEvent  = "participant.deleted"
+	ClassChangedEvent        = "class.changed"
+	EvaluationSubmittedEvent = "evaluation.submitted"
+	CheckinEvent             = "checkin"
+)
+
+// WebhookEvents is the list of all event types.
+var WebhookEvents = []string{
+	ParticipantAddedEvent,
+	ParticipantUpdatedEvent,
+	ParticipantDeletedEvent,
+	ClassChangedEvent,
+	EvaluationSubmittedEvent,
+	CheckinEvent,
+}
+
+// Webhook is a subscription to event notifications.
+type Webhook struct {
+	ID     int64  `json:"id" datastore:"-"`
+	URL    string `json:"url" datastore:"url,noindex"`
+	Secret string `json:"secret" datastore:"secret,noindex"`
+
+	// Events to deliver. All events are delivered if the slice is empty.
+	Events []string `json:"events" datastore:"events,noindex"`
+
+	Created time.Time `json:"created" datastore:"created,noindex"`
+}
+
+// Wants returns true if the webhook is subscribed to the event type.
+func (h *Webhook) Wants(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records the delivery of an event to a webhook. The
+// delivery is recorded before the first attempt and is retried until it
+// succeeds or the attempts are exhausted.
+type WebhookDelivery struct {
+	ID        int64     `json:"id" datastore:"-"`
+	WebhookID int64     `json:"webhookID" datastore:"webhookID,noindex"`
+	URL       string    `json:"url" datastore:"url,noindex"`
+	Event     string    `json:"event" datastore:"event,noindex"`
+	EventID   string    `json:"eventID" datastore:"eventID,noindex"`
+	Time      time.Time `json:"time" datastore:"time"`
+	Attempts  int       `json:"attempts" datastore:"attempts,noindex"`
+
+	// HTTP status code of the last attempt, zero if no response.
+	Status int    `json:"status" datastore:"status,noindex"`
+	Error  string `json:"error" datastore:"error,noindex,omitempty"`
+
+	// Pending is true while the delivery is waiting for an attempt.
+	Pending     bool      `json:"pending" datastore:"pending"`
+	NextAttempt time.Time `json:"nextAttempt" datastore:"nextAttempt,noindex"`
+
+	// JSON encoded event. The body is cleared when the delivery is no
+	// longer pending.
+	Body string `json:"-" datastore:"body,noindex,omitempty"`
+}
+
+func (h *Webhook) Load(ps []datastore.Property) error {
+	return datastore.LoadStruct(h, ps)
+}
+
+func (h *Webhook) LoadKey(k *datastore.Key) error {
+	h.ID = k.ID
+	return nil
+}
+
+func (h *Webhook) Save() ([]datastore.Property, error) {
+	return datastore.SaveStruct(h)
+}
+
+func (d *WebhookDelivery) Load(ps []datastore.Property) error {
+	return datastore.LoadStruct(d, ps)
+}
+
+func (d *WebhookDelivery) LoadKey(k *datastore.Key) error {
+	d.ID = k.ID
+	return nil
+}
+
+func (d *WebhookDelivery) Save() ([]datastore.Property, error) {
+	return datastore.SaveStruct(d)
+}
@@ -0,0 +1,87 @@
+package pdf
+
+// Font is one of the standard PDF fonts.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	TimesRoman
+	TimesBold
+)
+
+type fontMetrics struct {
+	name string
+
+	// Widths in thousandths of the font size for the characters from 32
+	// (space) through 126 (~).
+	widths [95]int
+
+	// Width of other characters.
+	defaultWidth int
+}
+
+var fonts = []*fontMetrics{
+	Helvetica: {
+		name:         "Helvetica",
+		defaultWidth: 556,
+		widths: [95]int{
+			278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+			1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+			667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+			333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+			556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+		},
+	},
+	HelveticaBold: {
+		name:         "Helvetica-Bold",
+		defaultWidth: 611,
+		widths: [95]int{
+			278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+			556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+			975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+			667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+			333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+			611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+		},
+	},
+	TimesRoman: {
+		name:         "Times-Roman",
+		defaultWidth: 500,
+		widths: [95]int{
+			250, 333, 408, 500, 500, 833, 778, 180, 333, 333, 500, 564, 250, 333, 250, 278,
+			500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 278, 278, 564, 564, 564, 444,
+			921, 722, 667, 667, 722, 611, 556, 722, 722, 333, 389, 722, 611, 889, 722, 722,
+			556, 722, 667, 556, 611, 722, 722, 944, 722, 722, 611, 333, 278, 333, 469, 500,
+			333, 444, 500, 444, 500, 444, 333, 500, 500, 278, 278, 500, 278, 778, 500, 500,
+			500, 500, 333, 389, 278, 500, 500, 722, 500, 500, 444, 480, 200, 480, 541,
+		},
+	},
+	TimesBold: {
+		name:         "Times-Bold",
+		defaultWidth: 556,
+		widths: [95]int{
+			250, 333, 555, 500, 500, 1000, 833, 278, 333, 333, 500, 570, 250, 333, 250, 278,
+			500, 500, 500, 500, 500, 500, 500, 500, 500, 500, 333, 333, 570, 570, 570, 500,
+			930, 722, 667, 722, 722, 667, 611, 778, 778, 389, 500, 778, 667, 944, 722, 778,
+			611, 778, 722, 556, 667, 722, 722, 1000, 722, 722, 667, 333, 278, 333, 581, 500,
+			333, 500, 556, 444, 556, 444, 333, 500, 556, 278, 333, 556, 278, 833, 556, 500,
+			556, 556, 444, 389, 333, 556, 500, 722, 500, 500, 444, 394, 220, 394, 520,
+		},
+	},
+}
+
+// StringWidth returns the width of s in points.
+func StringWidth(font Font, size float64, s string) float64 {
+	m := fonts[font]
+	w := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			w += m.widths[r-32]
+		} else {
+			w += m.defaultWidth
+		}
+	}
+	return float64(w) * size / 1000
+}
@@ -0,0 +1,282 @@
+// Package pdf writes simple PDF documents using the standard fonts.
+//
+// Coordinates are in points with the origin at the bottom left corner of the
+// page. The output does not include timestamps or other varying data, so
+// the same document always produces the same bytes.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the MIME type for PDF files.
+const ContentType = "application/pdf"
+
+// Inch is the number of points in an inch.
+const Inch = 72.0
+
+// Page sizes in points.
+const (
+	LetterWidth  = 8.5 * Inch
+	LetterHeight = 11 * Inch
+)
+
+// Document is a PDF document.
+type Document struct {
+	pages []*Page
+}
+
+// New returns an empty document.
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage adds a page with the given size in points.
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{Width: width, Height: height, fonts: make(map[Font]bool)}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// NumPages returns the number of pages in the document.
+func (d *Document) NumPages() int {
+	return len(d.pages)
+}
+
+// Page is a page in a document.
+type Page struct {
+	Width  float64
+	Height float64
+
+	content bytes.Buffer
+	fonts   map[Font]bool
+}
+
+// Align specifies the horizontal alignment of text relative to x.
+type Align int
+
+const (
+	Left Align = iota
+	Center
+	Right
+)
+
+// Text draws s with the baseline at y.
+func (p *Page) Text(x, y float64, font Font, size float64, align Align, s string) {
+	switch align {
+	case Center:
+		x -= StringWidth(font, size, s) / 2
+	case Right:
+		x -= StringWidth(font, size, s)
+	}
+	p.fonts[font] = true
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (", font, num(size), num(x), num(y))
+	writeString(&p.content, s)
+	p.content.WriteString(") Tj ET\n")
+}
+
+// RotatedText draws s with the start of the baseline at (x, y) rotated
+// counterclockwise by degrees.
+func (p *Page) RotatedText(x, y, degrees float64, font Font, size float64, s string) {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	p.fonts[font] = true
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s %s %s %s %s Tm (", font, num(size),
+		num(cos), num(sin), num(-sin), num(cos), num(x), num(y))
+	writeString(&p.content, s)
+	p.content.WriteString(") Tj ET\n")
+}
+
+// FitText draws s using the largest font size not greater than size that
+// fits in width.
+func (p *Page) FitText(x, y, width float64, font Font, size float64, align Align, s string) {
+	if w := StringWidth(font, size, s); w > width {
+		size = size * width / w
+	}
+	p.Text(x, y, font, size, align, s)
+}
+
+// Wrap breaks s into lines no wider than width. Words wider than width are
+// placed on a line by themselves.
+func Wrap(font Font, size, width float64, s string) []string {
+	var (
+		lines []string
+		line  string
+	)
+	for _, word := range strings.Fields(s) {
+		if line == "" {
+			line = word
+		} else if StringWidth(font, size, line+" "+word) <= width {
+			line += " " + word
+		} else {
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// SetGray sets the fill and stroke color to a gray level between 0 (black)
+// and 1 (white).
+func (p *Page) SetGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s g %s G\n", num(gray), num(gray))
+}
+
+// Line strokes a line from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(lineWidth), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect strokes a rectangle with the bottom left corner at (x, y).
+func (p *Page) Rect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n", num(lineWidth), num(x), num(y), num(w), num(h))
+}
+
+// FillRect fills a rectangle with the bottom left corner at (x, y).
+func (p *Page) FillRect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// num formats f with at most three decimal places.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+}
+
+// writeString writes s as the contents of a PDF literal string in
+// WinAnsiEncoding. Characters that cannot be encoded are written as '?'.
+func writeString(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		b, ok := winAnsi(r)
+		if !ok {
+			b = '?'
+		}
+		switch b {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		default:
+			if b < 32 || b > 126 {
+				fmt.Fprintf(buf, "\\%03o", b)
+			} else {
+				buf.WriteByte(b)
+			}
+		}
+	}
+}
+
+// winAnsiSpecial maps the characters in the 0x80-0x9f range of
+// WinAnsiEncoding.
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+func winAnsi(r rune) (byte, bool) {
+	switch {
+	case r >= 32 && r <= 126:
+		return byte(r), true
+	case r >= 0xa0 && r <= 0xff:
+		return byte(r), true
+	}
+	b, ok := winAnsiSpecial[r]
+	return b, ok
+}
+
+// WriteTo writes the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	var offsets []int64
+	obj := func(format string, args ...interface{}) {
+		bw.Flush()
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(bw, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(bw, format, args...)
+		bw.WriteString("\nendobj\n")
+	}
+	stream := func(dict string, data []byte) {
+		bw.Flush()
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(bw, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dict, len(data))
+		bw.Write(data)
+		bw.WriteString("\nendstream\nendobj\n")
+	}
+
+	bw.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers: 1 catalog, 2 pages, 3.. fonts, then a page and
+	// content stream object for each page.
+	fontObj := make(map[Font]int)
+	for i := range fonts {
+		fontObj[Font(i)] = 3 + i
+	}
+	firstPageObj := 3 + len(fonts)
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPageObj+2*i))
+	}
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+
+	for _, f := range fonts {
+		obj("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.name)
+	}
+
+	for i, p := range d.pages {
+		var resources []string
+		for f := range fonts {
+			if p.fonts[Font(f)] {
+				resources = append(resources, fmt.Sprintf("/F%d %d 0 R", f, fontObj[Font(f)]))
+			}
+		}
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			num(p.Width), num(p.Height), strings.Join(resources, " "), firstPageObj+2*i+1)
+
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(p.content.Bytes())
+		zw.Close()
+		stream("/Filter /FlateDecode", buf.Bytes())
+	}
+
+	bw.Flush()
+	xref := cw.n
+	fmt.Fprintf(bw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(bw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(bw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, cw.err
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	if err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return n, err
+}
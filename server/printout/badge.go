@@ -0,0 +1,199 @@
+package printout
+
+import (
+	"fmt"
+	"strings"
+
+	"rsc.io/qr"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+)
+
+// BadgeData is the data for a name badge.
+type BadgeData struct {
+	// Nickname or first name.
+	FirstName string
+	LastName  string
+
+	// Unit, district and council.
+	Lines []string
+
+	// Staff role or "Staff" for staff without a role.
+	Role string
+
+	OABanquet bool
+
+	// Short name of the participant's lunch.
+	Lunch string
+
+	// Contact vCard for the QR code. No QR code is drawn if empty.
+	VCard string
+
+	Year int
+}
+
+// NewBadgeData returns the badge data for a participant. The badge includes
+// a contact QR code if the participant asked for one at registration.
+func NewBadgeData(participant *model.Participant, lunch *model.Lunch, year int) *BadgeData {
+	b := &BadgeData{
+		FirstName: participant.NicknameOrFirstName(),
+		LastName:  participant.LastName,
+		Role:      participant.StaffRole,
+		OABanquet: participant.OABanquet,
+		Year:      year,
+	}
+	if b.Role == "" && participant.Staff {
+		b.Role = "Staff"
+	}
+	if lunch != nil {
+		b.Lunch = lunch.ShortName
+	}
+	if u := participant.Unit(); u != "" {
+		b.Lines = append(b.Lines, u)
+	}
+	if d := participant.District; d != "" {
+		if d != "Council" {
+			d += " District"
+		}
+		b.Lines = append(b.Lines, d)
+	}
+	if c := participant.Council; c != "" {
+		b.Lines = append(b.Lines, c+" Council")
+	}
+	if participant.ShowQRCode && (participant.Phone != "" || participant.Email != "") {
+		b.VCard = VCard("FN", participant.Name(), "TEL", participant.Phone, "EMAIL", participant.Email)
+	}
+	return b
+}
+
+// VCard returns a vCard with the given property name and value pairs.
+// Properties with empty values are omitted.
+func VCard(props ...string) string {
+	var buf strings.Builder
+	buf.WriteString("BEGIN:VCARD\r\nVERSION:4.0\r\n")
+	for i := 0; i+1 < len(props); i += 2 {
+		value := strings.TrimSpace(props[i+1])
+		if value == "" {
+			continue
+		}
+		buf.WriteString(props[i])
+		buf.WriteByte(':')
+		for j := 0; j < len(value); j++ {
+			b := value[j]
+			switch b {
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case ',':
+				buf.WriteString(`\,`)
+			case ':':
+				buf.WriteString(`\:`)
+			case ';':
+				buf.WriteString(`\;`)
+			default:
+				buf.WriteByte(b)
+			}
+		}
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("END:VCARD\r\n")
+	return buf.String()
+}
+
+// Badge draws a name badge in r. The size of the text is scaled from size,
+// the size of the first name. Layout distances are scaled from the height
+// of a 2-1/2" badge.
+func Badge(p *pdf.Page, r Rect, size float64, b *BadgeData) {
+	const pad = 0.125 * pdf.Inch
+	scale := size / 26
+	hscale := r.H / (2.5 * pdf.Inch)
+
+	// Text is centered in the space to the right of the QR code.
+	left := r.X + pad
+	if b.VCard != "" {
+		q := 1.45 * pdf.Inch * hscale
+		if code, err := qr.Encode(b.VCard, qr.L); err == nil {
+			QRCode(p, Rect{X: r.X + 0.1*pdf.Inch, Y: r.Y + r.H - 0.16*pdf.Inch*hscale - q, W: q, H: q}, code)
+			left = r.X + 0.1*pdf.Inch + q + pad
+		}
+	}
+	w := r.X + r.W - pad - left
+	x := left + w/2
+
+	y := r.Y + r.H - pad - 26*scale
+	p.FitText(x, y, w, pdf.HelveticaBold, 26*scale, pdf.Center, b.FirstName)
+	y -= 18 * scale
+	p.FitText(x, y, w, pdf.HelveticaBold, 15*scale, pdf.Center, b.LastName)
+	y -= 6 * scale
+	for _, line := range b.Lines {
+		y -= 14 * scale
+		p.FitText(x, y, w, pdf.Helvetica, 12*scale, pdf.Center, line)
+	}
+
+	footer := r.Y + 0.2*pdf.Inch + 20*scale
+	p.Line(r.X+pad, footer, r.X+r.W-pad, footer, 0.75)
+	p.FitText(r.X+r.W/2, footer-11*scale, r.W-2*pad, pdf.Helvetica, 9*scale, pdf.Center,
+		fmt.Sprintf("%d Program & Training Conference", b.Year))
+	p.FitText(r.X+r.W/2, footer-22*scale, r.W-2*pad, pdf.Helvetica, 9*scale, pdf.Center,
+		"Chief Seattle Council · Boy Scouts of America")
+
+	// Markers are right aligned above the footer. The role is white on
+	// black so that staff are easy to find.
+	mx := r.X + r.W - pad
+	my := footer + 5*scale
+	marker := func(s string, inverse bool) {
+		const msize = 9
+		mw := pdf.StringWidth(pdf.HelveticaBold, msize*scale, s) + 8*scale
+		mh := 14 * scale
+		mx -= mw
+		if inverse {
+			p.FillRect(mx, my, mw, mh)
+			p.SetGray(1)
+		} else {
+			p.Rect(mx, my, mw, mh, 0.75)
+		}
+		p.Text(mx+mw/2, my+4*scale, pdf.HelveticaBold, msize*scale, pdf.Center, s)
+		p.SetGray(0)
+		mx -= 4 * scale
+	}
+	if b.Lunch != "" {
+		marker(b.Lunch, false)
+	}
+	if b.OABanquet {
+		marker("OA", false)
+	}
+	if b.Role != "" {
+		marker(b.Role, true)
+	}
+}
+
+// QRCode draws the code in r.
+func QRCode(p *pdf.Page, r Rect, code *qr.Code) {
+	m := r.W / float64(code.Size)
+	for y := 0; y < code.Size; y++ {
+		// Fill runs of black modules in the row.
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < code.Size && code.Black(x, y) {
+				x++
+			}
+			p.FillRect(r.X+float64(start)*m, r.Y+r.H-float64(y+1)*m, float64(x-start)*m, m)
+		}
+	}
+}
+
+// Badges adds sheets of name badges.
+func Badges(doc *pdf.Document, t *model.LabelTemplate, badges []*BadgeData) {
+	size := fontSize(t, 26)
+	Labels(doc, t, len(badges), func(p *pdf.Page, i int, r Rect) {
+		Badge(p, r, size, badges[i])
+	})
+}
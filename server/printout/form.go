@@ -0,0 +1,329 @@
+package printout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+)
+
+// FormData is the data for a participant form.
+type FormData struct {
+	Participant *model.Participant
+	Classes     []*model.SessionClass // indexed by session
+	Lunch       *model.Lunch
+	Conference  *model.Conference
+	Year        int
+}
+
+const (
+	formMargin = 0.5 * pdf.Inch
+	formRight  = pdf.LetterWidth - formMargin
+	formWidth  = formRight - formMargin
+)
+
+// fromTop converts a distance in inches from the top of the page to a y
+// coordinate.
+func fromTop(in float64) float64 {
+	return pdf.LetterHeight - in*pdf.Inch
+}
+
+// AddForm adds the evaluation and participation pages for a participant.
+// The evaluation page is first so that it prints on the back of the
+// participation page when printing duplex.
+func AddForm(doc *pdf.Document, d *FormData) {
+	addEvaluationPage(doc, d)
+	addParticipationPage(doc, d)
+}
+
+func formTitle(d *FormData) string {
+	return fmt.Sprintf("%d PTC · Evaluation & Participation Form", d.Year)
+}
+
+func classTitle(sc *model.SessionClass) string {
+	s := sc.ShortTitle() + sc.IofN()
+	if sc.Number != 0 {
+		s = fmt.Sprintf("%d: %s", sc.Number, s)
+	}
+	return s
+}
+
+var (
+	classQuestions = []string{
+		"Instructor's knowledge of course material",
+		"Presentation of material",
+		"Usefulness of topic",
+		"Session overall",
+	}
+	conferenceQuestions = []string{
+		"Overall conference experience",
+		"Pre-event promotion",
+		"Online registration (if applicable)",
+		"On-site check-in process",
+		"Midway",
+		"Lunch",
+		"Facilities",
+		"Mobile website (seaptc.org)",
+		"Signage and wayfinding",
+	}
+)
+
+func addEvaluationPage(doc *pdf.Document, d *FormData) {
+	p := doc.AddPage(pdf.LetterWidth, pdf.LetterHeight)
+
+	// Online submission note in the top right corner.
+	const noteWidth = 2.05 * pdf.Inch
+	p.SetGray(0.96)
+	p.FillRect(formRight-noteWidth, fromTop(0.95), noteWidth, 0.5*pdf.Inch)
+	p.SetGray(0)
+	y := fromTop(0.65)
+	for _, line := range pdf.Wrap(pdf.Helvetica, 9, noteWidth-8, "Submit your evaluation online instead! Enter the evaluation code from each instructor at seaptc.org.") {
+		p.Text(formRight-noteWidth+4, y, pdf.Helvetica, 9, pdf.Left, line)
+		y -= 11
+	}
+
+	p.Text(formMargin, fromTop(0.65), pdf.HelveticaBold, 11, pdf.Left, formTitle(d))
+	p.Text(formMargin, fromTop(0.82), pdf.Helvetica, 9, pdf.Left, "Please mark the evaluation for each class using a scale of 1 (Poor) to 4 (Great).")
+	p.Text(formMargin, fromTop(0.97), pdf.Helvetica, 9, pdf.Left, "Return form to session 6 instructor or PTC Admin in College Center lobby.")
+
+	const (
+		row      = 14
+		label    = 2.375 * pdf.Inch
+		box      = 0.25 * pdf.Inch
+		comments = formMargin + label + 4*box
+	)
+
+	// ratings draws the rating header and a row of boxes for each question
+	// with the top at y. The function returns the bottom of the last row.
+	ratings := func(y float64, questions []string) float64 {
+		for i, s := range []string{"poor 1", "2", "3", "great 4"} {
+			x := formMargin + label + float64(i)*box
+			p.SetGray(0.96)
+			p.FillRect(x, y-row, box, row)
+			p.SetGray(0)
+			p.Rect(x, y-row, box, row, 0.5)
+			p.FitText(x+box/2, y-row+3, box-2, pdf.Helvetica, 7, pdf.Center, s)
+		}
+		y -= row
+		for _, q := range questions {
+			p.FitText(formMargin+2, y-row+3.5, label-4, pdf.Helvetica, 8.5, pdf.Left, q)
+			p.Line(formMargin, y-row, formMargin+label, y-row, 0.5)
+			for i := 0; i < 4; i++ {
+				p.Rect(formMargin+label+float64(i)*box, y-row, box, row, 0.5)
+			}
+			y -= row
+		}
+		return y
+	}
+
+	y = fromTop(1.1)
+	for _, sc := range d.Classes {
+		p.Line(formMargin, y, formRight, y, 1.5)
+		p.FitText(formMargin+label+2, y-row+3, formRight-formMargin-label-4, pdf.HelveticaBold, 10, pdf.Left, classTitle(sc))
+
+		// Attendance sticker box spanning the title and rating header rows.
+		p.SetGray(0.96)
+		p.FillRect(formMargin+2, y-2*row+2, 1.75*pdf.Inch, 2*row-4)
+		p.SetGray(0)
+		p.Rect(formMargin+2, y-2*row+2, 1.75*pdf.Inch, 2*row-4, 1.5)
+		if sc.Number != 0 {
+			p.Text(formMargin+2+0.875*pdf.Inch, y-row+1, pdf.Helvetica, 8, pdf.Center,
+				fmt.Sprintf("Stick %s attendance", sc.NumberDotPart()))
+			p.Text(formMargin+2+0.875*pdf.Inch, y-2*row+6, pdf.Helvetica, 8, pdf.Center, "sticker from instructor here.")
+		}
+
+		c := "Comments:"
+		if sc.Instructor {
+			c = "Instructor's Comments:"
+		}
+		p.Text(comments+6, y-2*row+3, pdf.Helvetica, 8.5, pdf.Left, c)
+		y = ratings(y-row, classQuestions)
+	}
+
+	p.Line(formMargin, y, formRight, y, 1.5)
+	p.Text(formMargin+2, y-row+3, pdf.HelveticaBold, 10, pdf.Left, "Conference Evaluation")
+	cy := y - row + 3
+	for _, line := range []string{
+		"What new subject should we add to the PTC next year?",
+		"",
+		"",
+		"Is there a subject that you would like to teach at the PTC next year?",
+		"",
+		"",
+		"Comments:",
+	} {
+		if line != "" {
+			p.FitText(comments+6, cy, formRight-comments-8, pdf.Helvetica, 8.5, pdf.Left, line)
+		}
+		cy -= row
+	}
+	y = ratings(y, conferenceQuestions)
+	p.Line(formMargin, y, formRight, y, 1.5)
+}
+
+func addParticipationPage(doc *pdf.Document, d *FormData) {
+	p := doc.AddPage(pdf.LetterWidth, pdf.LetterHeight)
+	participant := d.Participant
+
+	p.Text(formMargin, fromTop(0.6), pdf.HelveticaBold, 11, pdf.Left, formTitle(d))
+
+	bt := d.Conference.LabelTemplate("form")
+	badge := labelRect(bt, 0, 0)
+	Badge(p, badge, fontSize(bt, 26), NewBadgeData(participant, d.Lunch, d.Year))
+
+	// Registration details to the right of the badge.
+	x := badge.X + badge.W + 0.25*pdf.Inch
+	w := formRight - x
+	y := fromTop(0.65)
+	p.FitText(x, y, w, pdf.HelveticaBold, 15, pdf.Left, participant.Name())
+	y -= 20
+	p.FitText(x, y, w, pdf.HelveticaBold, 9, pdf.Left, "Login code: "+participant.LoginCode)
+	line := func(s string) {
+		y -= 11
+		p.FitText(x, y, w, pdf.Helvetica, 9, pdf.Left, s)
+	}
+	if u := participant.Unit(); u != "" {
+		line(u)
+	}
+	if participant.District != "" {
+		s := participant.District
+		if s != "Council" {
+			s += " District"
+		}
+		line(s)
+	} else if participant.Council != "" {
+		line(participant.Council + " Council")
+	}
+	if participant.BSANumber != "" {
+		line("BSA # " + participant.BSANumber)
+	}
+	if participant.Phone != "" {
+		line("Phone: " + participant.Phone)
+	}
+	if participant.Email != "" {
+		line(participant.Email)
+	}
+	if participant.Address != "" && participant.City != "" && participant.State != "" && participant.Zip != "" {
+		line(participant.Address)
+		line(fmt.Sprintf("%s, %s %s", participant.City, participant.State, participant.Zip))
+	}
+	if participant.StaffRole != "" {
+		line("Staff: " + participant.StaffRole)
+	}
+
+	// Instructions start at a fixed position unless the registration
+	// details are long.
+	y = math.Min(y-14, fromTop(2.2))
+	lines := pdf.Wrap(pdf.Helvetica, 9, w, "Instructions: Submit evaluation using mobile device at seaptc.org or complete the "+
+		"evaluation form on the back of this page. Evaluations must be submitted because they serve as your "+
+		"official training record today and will be used to plan next year's PTC. Return form to session 6 "+
+		"instructor or to PTC Administration (located in College Center lobby). Get the event patch by turning "+
+		"in the form or by showing seaptc.org confirmation page.")
+	for _, s := range lines {
+		p.Text(x, y, pdf.Helvetica, 9, pdf.Left, s)
+		y -= 11
+	}
+
+	// Schedule.
+	y = badge.Y - 0.19*pdf.Inch - 11
+	p.Text(formMargin, y, pdf.HelveticaBold, 11, pdf.Left, participant.Firsts()+" Conference Schedule")
+	y -= 8
+
+	const (
+		row      = 22
+		timeCol  = 0.95 * pdf.Inch
+		location = 1.6 * pdf.Inch
+	)
+	p.Line(formMargin, y, formRight, y, 1.5)
+	item := func(t, title, loc string, bold bool) {
+		y -= row
+		p.Text(formMargin+2, y+7, pdf.Helvetica, 11, pdf.Left, t)
+		font := pdf.Helvetica
+		if bold {
+			font = pdf.HelveticaBold
+		}
+		tw := formWidth - timeCol - 4
+		if loc != "" {
+			tw -= location
+			p.FitText(formRight-location, y+7, location-2, pdf.Helvetica, 11, pdf.Left, loc)
+		}
+		p.FitText(formMargin+timeCol, y+7, tw, font, 11, pdf.Left, title)
+		p.Line(formMargin, y, formRight, y, 0.75)
+	}
+	class := func(t string, session int) {
+		sc := d.Classes[session]
+		title := classTitle(sc)
+		if sc.Instructor {
+			title = "Instructor " + title
+		}
+		item(t, title, sc.Location, false)
+	}
+	lunch := func(t string) {
+		s := "Lunch: Pick up your lunch at your assigned location"
+		if participant.DietaryRestrictions != "" {
+			s = "Lunch: " + participant.DietaryRestrictions + " meal at your assigned location"
+		}
+		item(t, s, d.Lunch.Location, false)
+	}
+
+	conf := d.Conference
+	item("7:40-8:15", "Check-in and Registration", conf.OpeningLocation, false)
+	item("8:15-8:45", "Opening Ceremony", conf.OpeningLocation, false)
+	class("9:00-10:00", 0)
+	item("10:00-10:10", "Break – Visit the Midway or Scout Shop", "", false)
+	class("10:10-11:10", 1)
+	if d.Lunch.Seating == 1 {
+		lunch("11:10-12:15")
+		class("12:15-1:15", 2)
+		item("1:15-1:25", "Break – Visit the Scout Shop", "", false)
+	} else {
+		item("11:10-11:20", "Break – Visit the Midway or Scout Shop", "", false)
+		class("11:20-12:20", 2)
+		lunch("12:20-1:25")
+	}
+	class("1:25-2:25", 3)
+	item("2:25-2:35", "Break – Visit the Scout Shop", "", false)
+	class("2:35-3:35", 4)
+	item("3:35-3:45", "Break – Visit the Scout Shop", "", false)
+	class("3:45-4:45", 5)
+	if participant.OABanquet {
+		item("5:30-9:00", "Order of the Arrow Banquet", conf.OABanquetLocation, false)
+	}
+	p.Line(formMargin, y, formRight, y, 1.5)
+
+	// Notes below the schedule. The right side is left clear for the name
+	// printed along the edge of the page.
+	paragraphs := []string{"Questions? PTC Administration is located in the College Center lobby."}
+	if !participant.OABanquet {
+		paragraphs = append(paragraphs, "The Scout Shop is open from 7:30 AM to 5:00 PM in the College Center lobby.")
+	}
+	paragraphs = append(paragraphs,
+		"Visit the Midway between 9:00 AM and 2:40 PM to meet representatives from scouting groups, "+
+			"programs and camps. The Midway is located on the upper level of the Wellness Center.",
+		"Login to seaptc.org with code "+participant.LoginCode+" to view your schedule and complete your evaluation.",
+		"CC College Center • ED Education • IB Instruction Building • HS Health Science & Student Resources Building")
+	y -= 6
+	for _, para := range paragraphs {
+		y -= 6
+		for _, s := range pdf.Wrap(pdf.Helvetica, 10, 7*pdf.Inch-0.75*pdf.Inch, para) {
+			y -= 12
+			p.Text(formMargin, y, pdf.Helvetica, 10, pdf.Left, s)
+		}
+	}
+
+	// Name along the right edge for sorting printed forms.
+	sx := formRight - 2
+	sy := 0.5*pdf.Inch + 1.5*pdf.Inch
+	last := participant.LastName
+	size := 15.0
+	if len(last) > 13 {
+		size = 13
+	}
+	p.RotatedText(sx-size, sy, -90, pdf.HelveticaBold, size, last)
+	first := participant.FirstName
+	if participant.Suffix != "" {
+		first += ", " + participant.Suffix
+	}
+	p.RotatedText(sx-size-14, sy, -90, pdf.Helvetica, 12, first)
+}
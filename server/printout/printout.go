@@ -0,0 +1,107 @@
+// Package printout renders participant forms, badges and sticker sheets as
+// PDF documents.
+package printout
+
+import (
+	"strings"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+)
+
+// Rect is a rectangle on a page in points with the origin at the bottom
+// left corner.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// labelRect returns the rectangle for the label at row and column.
+func labelRect(t *model.LabelTemplate, row, column int) Rect {
+	_, ph := t.Page()
+	x := t.Left + float64(column)*(t.Width+t.ColumnGap)
+	y := ph - t.Top - float64(row)*(t.Height+t.RowGap) - t.Height
+	return Rect{X: x * pdf.Inch, Y: y * pdf.Inch, W: t.Width * pdf.Inch, H: t.Height * pdf.Inch}
+}
+
+// Labels adds pages for n labels in the layout of template t. Labels are
+// placed left to right and then top to bottom. The function draw is called
+// to draw label i.
+func Labels(doc *pdf.Document, t *model.LabelTemplate, n int, draw func(p *pdf.Page, i int, r Rect)) {
+	pw, ph := t.Page()
+	var p *pdf.Page
+	for i := 0; i < n; i++ {
+		j := i % t.PerPage()
+		if j == 0 {
+			p = doc.AddPage(pw*pdf.Inch, ph*pdf.Inch)
+		}
+		draw(p, i, labelRect(t, j/t.Columns, j%t.Columns))
+	}
+}
+
+// fontSize returns the template font size or def if the template does not
+// specify a size.
+func fontSize(t *model.LabelTemplate, def float64) float64 {
+	if t.FontSize > 0 {
+		return t.FontSize
+	}
+	return def
+}
+
+// joinTail joins the lines after the first n-1 lines so that at most n lines
+// remain. FitText shrinks the joined line to fit.
+func joinTail(lines []string, n int) []string {
+	if len(lines) <= n {
+		return lines
+	}
+	return append(lines[:n-1:n-1], strings.Join(lines[n-1:], " "))
+}
+
+// LunchSticker draws a sticker for a participant with dietary restrictions
+// in r. Stickers for participants with allergies are outlined and the
+// allergy notes are shown in bold.
+func LunchSticker(p *pdf.Page, r Rect, size float64, participant *model.Participant, lunch *model.Lunch, severity string) {
+	const pad = 0.1 * pdf.Inch
+	x := r.X + r.W/2
+	w := r.W - 2*pad
+	small := size * 0.8
+
+	if severity == model.DietaryAllergy {
+		p.Rect(r.X+pad/2, r.Y+pad/2, r.W-pad, r.H-pad, 2)
+	}
+
+	lines := pdf.Wrap(pdf.Helvetica, small, w, participant.DietaryRestrictions)
+	var allergies []string
+	if participant.Allergies != "" {
+		allergies = pdf.Wrap(pdf.HelveticaBold, small, w, "ALLERGY: "+participant.Allergies)
+	}
+	switch {
+	case len(allergies) == 0:
+		lines = joinTail(lines, 2)
+	case len(lines) == 0:
+		allergies = joinTail(allergies, 2)
+	default:
+		lines = joinTail(lines, 1)
+		allergies = joinTail(allergies, 1)
+	}
+	height := size + float64(len(lines)+len(allergies)+1)*small*1.2
+	y := r.Y + (r.H+height)/2 - size
+	p.FitText(x, y, w, pdf.HelveticaBold, size, pdf.Center, participant.Name())
+	for _, line := range lines {
+		y -= small * 1.2
+		p.FitText(x, y, w, pdf.Helvetica, small, pdf.Center, line)
+	}
+	for _, line := range allergies {
+		y -= small * 1.2
+		p.FitText(x, y, w, pdf.HelveticaBold, small, pdf.Center, line)
+	}
+	y -= small * 1.2
+	p.FitText(x, y, w, pdf.Helvetica, small, pdf.Center, lunch.Name+" @ "+lunch.Location)
+}
+
+// LunchStickers adds sheets of lunch stickers for the participants.
+func LunchStickers(doc *pdf.Document, t *model.LabelTemplate, participants []*model.Participant, lunch func(*model.Participant) *model.Lunch, severity func(*model.Participant) string) {
+	size := fontSize(t, 16)
+	Labels(doc, t, len(participants), func(p *pdf.Page, i int, r Rect) {
+		LunchSticker(p, r, size, participants[i], lunch(participants[i]), severity(participants[i]))
+	})
+}
@@ -0,0 +1,139 @@
+// Package rooms proposes room assignments for classes.
+package rooms
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/seaptc/server/model"
+)
+
+// Assignment is the proposed room for a class. A class uses the same room
+// in all of its sessions.
+type Assignment struct {
+	Class      *model.Class
+	Registered int
+
+	// Demand is the number of seats needed: the registration count plus
+	// headroom.
+	Demand int
+
+	// Room is the proposed room or nil if no room in the inventory is free
+	// in all sessions of the class.
+	Room *model.Room
+
+	// Fixed is true if the class location is not in the room inventory. The
+	// location of fixed classes is not changed.
+	Fixed bool
+}
+
+// Location returns the proposed location for the class.
+func (a *Assignment) Location() string {
+	if a.Fixed || a.Room == nil {
+		return a.Class.Location
+	}
+	return a.Room.Name
+}
+
+// Changed returns true if the proposed location differs from the current
+// location.
+func (a *Assignment) Changed() bool {
+	return !strings.EqualFold(a.Location(), a.Class.Location)
+}
+
+// Overflow returns true if the proposed room is smaller than the demand.
+func (a *Assignment) Overflow() bool {
+	return !a.Fixed && (a.Room == nil || a.Room.Capacity < a.Demand)
+}
+
+// Assign proposes a room for each class from the room inventory. The
+// registered argument is the number of participants registered in each
+// class. Headroom is the percentage of seats to add to the registration
+// count for late registrations.
+//
+// Classes are assigned in decreasing order of demand. A class is assigned
+// the smallest free room with the features the class needs and enough
+// seats, preferring the current room when it fits. If no free room has
+// enough seats, the largest free room is assigned.
+func Assign(classes []*model.Class, rooms []*model.Room, registered map[int]int, headroom int) []*Assignment {
+	byName := make(map[string]*model.Room)
+	for _, r := range rooms {
+		byName[strings.ToLower(r.Name)] = r
+	}
+
+	// Rooms by increasing capacity.
+	rooms = append([]*model.Room(nil), rooms...)
+	sort.SliceStable(rooms, func(i, j int) bool { return rooms[i].Capacity < rooms[j].Capacity })
+
+	var assignments []*Assignment
+	for _, c := range classes {
+		n := registered[c.Number]
+		assignments = append(assignments, &Assignment{
+			Class:      c,
+			Registered: n,
+			Demand:     n + (n*headroom+99)/100,
+			Fixed:      c.Location != "" && byName[strings.ToLower(c.Location)] == nil,
+		})
+	}
+
+	order := append([]*Assignment(nil), assignments...)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		switch {
+		case a.Demand != b.Demand:
+			return a.Demand > b.Demand
+		case a.Class.Length != b.Class.Length:
+			return a.Class.Length > b.Class.Length
+		default:
+			return a.Class.Number < b.Class.Number
+		}
+	})
+
+	// used[room][session] is true if the room is assigned in the session.
+	used := make(map[*model.Room][]bool)
+	for _, r := range rooms {
+		used[r] = make([]bool, model.NumSession)
+	}
+	free := func(r *model.Room, c *model.Class) bool {
+		start, end := c.StartEnd()
+		for i := start; i <= end && i < model.NumSession; i++ {
+			if used[r][i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, a := range order {
+		if a.Fixed {
+			continue
+		}
+		features := model.SplitComma(a.Class.RoomFeatures)
+		current := byName[strings.ToLower(a.Class.Location)]
+
+		var fit, largest *model.Room
+		for _, r := range rooms {
+			if !r.HasFeatures(features) || !free(r, a.Class) {
+				continue
+			}
+			largest = r
+			if r.Capacity >= a.Demand && fit == nil {
+				fit = r
+			}
+		}
+		if current != nil && current.Capacity >= a.Demand && current.HasFeatures(features) && free(current, a.Class) {
+			fit = current
+		}
+		a.Room = fit
+		if a.Room == nil {
+			a.Room = largest
+		}
+		if a.Room != nil {
+			start, end := a.Class.StartEnd()
+			for i := start; i <= end && i < model.NumSession; i++ {
+				used[a.Room][i] = true
+			}
+		}
+	}
+	return assignments
+}
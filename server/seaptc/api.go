@@ -13,7 +13,6 @@ import (
 	"github.com/garyburd/web/httperror"
 	"github.com/garyburd/web/templates"
 
-	"github.com/seaptc/server/dk"
 	"github.com/seaptc/server/model"
 	"github.com/seaptc/server/store"
 )
@@ -175,7 +174,7 @@ func (svc *apiService) Serve_api_uploadRegistrations(rc *requestContext) error {
 		return httperror.ErrForbidden
 	}
 
-	f, _, err := rc.request.FormFile("file")
+	f, fh, err := rc.request.FormFile("file")
 	if err == http.ErrMissingFile {
 		return &httperror.Error{Status: 400, Message: "Export file not uploaded"}
 	}
@@ -184,18 +183,20 @@ func (svc *apiService) Serve_api_uploadRegistrations(rc *requestContext) error {
 	}
 	defer f.Close()
 
-	participants, err := dk.ParseCSV(f)
+	participants, err := svc.parseRegistrations(rc.ctx, f, fh.Filename, rc.request.FormValue("profile"))
 	if err != nil {
 		return err
 	}
 
-	summary, err := svc.store.ImportParticipants(rc.ctx, participants)
+	result, err := svc.store.ImportParticipants(rc.ctx, participants)
 	if err != nil {
 		return err
 	}
+	svc.notifyParticipantImport(rc.ctx, result)
+	svc.recordRegistrationSnapshot(rc.ctx)
 
 	return svc.respond(rc, map[string]interface{}{
 		"count":   len(participants),
-		"summary": summary,
+		"summary": result.Summary,
 	})
 }
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/garyburd/web/cookie"
@@ -33,6 +34,9 @@ type application struct {
 
 	adminIDs       map[string]bool
 	conferenceDate time.Time
+
+	// Serializes imports of the registration export.
+	importMu sync.Mutex
 }
 
 type applicationService interface {
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/store"
+)
+
+// certificatePair is a participant who completed a class that grants
+// training credit.
+type certificatePair struct {
+	participant *model.Participant
+	class       *model.Class
+}
+
+// completedClass returns true if the participant completed the class. A
+// participant completes a class by registering for the class and attending
+// the conference. Attendance is shown by check in or by an evaluation of
+// the class. Participants marked as no show do not complete any classes.
+func completedClass(p *model.Participant, number int, evaluated bool) bool {
+	if p.NoShow {
+		return false
+	}
+	registered := false
+	for _, n := range p.Classes {
+		if n == number {
+			registered = true
+			break
+		}
+	}
+	return registered && (!p.CheckinTime.IsZero() || evaluated)
+}
+
+// participantCertificates returns the classes that grant training credit
+// completed by a participant.
+func (a *application) participantCertificates(ctx context.Context, id string) ([]certificatePair, error) {
+	p, err := a.store.GetParticipant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	evals, err := a.store.GetSessionEvaluations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	evaluated := make(map[int]bool)
+	for _, e := range evals {
+		evaluated[e.ClassNumber] = true
+	}
+
+	var result []certificatePair
+	for _, n := range p.Classes {
+		class, err := a.store.GetClass(ctx, n)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if class.Certificate != "" && completedClass(p, n, evaluated[n]) {
+			result = append(result, certificatePair{p, class})
+		}
+	}
+	return result, nil
+}
+
+// classCertificates returns the participants who completed a class that
+// grants training credit.
+func (a *application) classCertificates(ctx context.Context, number int) ([]certificatePair, error) {
+	class, err := a.store.GetClass(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if class.Certificate == "" {
+		return nil, nil
+	}
+	participants, err := a.store.GetClassParticipants(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	evals, err := a.store.GetAllSessionEvaluations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	evaluated := make(map[string]bool)
+	for _, e := range evals {
+		if e.ClassNumber == number {
+			evaluated[e.ParticipantID] = true
+		}
+	}
+
+	model.SortParticipants(participants, "")
+	var result []certificatePair
+	for _, p := range participants {
+		if completedClass(p, number, evaluated[p.ID]) {
+			result = append(result, certificatePair{p, class})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/garyburd/web/httperror"
+	"github.com/garyburd/web/templates"
+
+	"github.com/seaptc/server/dk"
+	"github.com/seaptc/server/model"
+)
+
+// cronService handles scheduled tasks. The handlers are invoked by App Engine
+// cron (see cron.yaml). When self-hosting, set the -importInterval flag to
+// run the registration import and webhook retries from in-process tickers.
+type cronService struct {
+	*application
+	importInterval time.Duration
+}
+
+func (svc *cronService) init(ctx context.Context, a *application, tm *templates.Manager) error {
+	svc.application = a
+	go svc.migrate()
+	if svc.importInterval > 0 {
+		go svc.runImportTicker()
+		go svc.runWebhookTicker()
+	}
+	return nil
+}
+
+func (svc *cronService) errorTemplate() *templates.Template {
+	return nil
+}
+
+func (svc *cronService) makeHandler(v interface{}) func(*requestContext) error {
+	f, ok := v.(func(*cronService, *requestContext) error)
+	if !ok {
+		return nil
+	}
+	return func(rc *requestContext) error { return f(svc, rc) }
+}
+
+// migrate updates entities stored by earlier versions of the application.
+// The migration does nothing once the entities are updated.
+func (svc *cronService) migrate() {
+	ctx := context.Background()
+	n, err := svc.store.MigrateParticipants(ctx)
+	if err != nil {
+		logf(ctx, "ERROR", "participant migration: %v", err)
+	} else if n > 0 {
+		logf(ctx, "INFO", "participant migration: %d participants updated", n)
+	}
+}
+
+func (svc *cronService) runImportTicker() {
+	ctx := context.Background()
+	t := time.NewTicker(svc.importInterval)
+	defer t.Stop()
+	for range t.C {
+		if _, err := svc.importRegistrationExport(ctx, model.ImportTriggerTicker); err != nil && err != errNoRegistrationExport {
+			logf(ctx, "ERROR", "scheduled registration import: %v", err)
+		}
+	}
+}
+
+// webhookRetryInterval is the interval of the in-process webhook retry
+// ticker. Keep in sync with cron.yaml.
+const webhookRetryInterval = 5 * time.Minute
+
+func (svc *cronService) runWebhookTicker() {
+	ctx := context.Background()
+	t := time.NewTicker(webhookRetryInterval)
+	defer t.Stop()
+	for range t.C {
+		if _, err := svc.retryWebhookDeliveries(ctx); err != nil {
+			logf(ctx, "ERROR", "webhook retry: %v", err)
+		}
+	}
+}
+
+// Serve_cron_webhooks retries failed and interrupted webhook deliveries.
+func (svc *cronService) Serve_cron_webhooks(rc *requestContext) error {
+	if rc.request.Header.Get("X-Appengine-Cron") != "true" && !svc.devMode {
+		return httperror.ErrForbidden
+	}
+	n, err := svc.retryWebhookDeliveries(rc.ctx)
+	if err != nil {
+		return err
+	}
+	rc.response.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rc.response, "Attempted %d webhook deliveries\n", n)
+	return nil
+}
+
+func (svc *cronService) Serve_cron_importRegistrations(rc *requestContext) error {
+	// App Engine removes this header from requests that do not originate
+	// from cron.
+	if rc.request.Header.Get("X-Appengine-Cron") != "true" && !svc.devMode {
+		return httperror.ErrForbidden
+	}
+	run, err := svc.importRegistrationExport(rc.ctx, model.ImportTriggerCron)
+	if err == errNoRegistrationExport {
+		rc.logf("registration export URL not set, import skipped")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	rc.response.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintf(rc.response, "Import %d records; %s\n", run.Count, run.Summary)
+	return nil
+}
+
+var errNoRegistrationExport = errors.New("registration export URL not set")
+
+// importRegistrationExport fetches the Doubleknot registration export
+// configured on the conference and imports the participants. Each run is
+// recorded in the import history.
+func (a *application) importRegistrationExport(ctx context.Context, trigger string) (*model.ImportRun, error) {
+	a.importMu.Lock()
+	defer a.importMu.Unlock()
+
+	conf, err := a.store.GetConference(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if conf.RegistrationExportURL == "" {
+		return nil, errNoRegistrationExport
+	}
+
+	run := &model.ImportRun{
+		Time:    time.Now(),
+		Trigger: trigger,
+	}
+
+	err = func() error {
+		header, err := parseHeaderLines(conf.RegistrationExportHeaders)
+		if err != nil {
+			return err
+		}
+		participants, err := dk.FetchCSV(ctx, conf.RegistrationExportURL, header, conf)
+		if err != nil {
+			return err
+		}
+		run.Count = len(participants)
+		if trigger != model.ImportTriggerManual {
+			if err := a.checkUnattendedImport(ctx, participants); err != nil {
+				run.Refused = true
+				return err
+			}
+		}
+		result, err := a.store.ImportParticipants(ctx, participants)
+		if err != nil {
+			return err
+		}
+		run.Summary = result.Summary
+		a.notifyParticipantImport(ctx, result)
+		a.recordRegistrationSnapshot(ctx)
+		return nil
+	}()
+
+	run.Duration = time.Since(run.Time)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if err := a.store.AddImportRun(ctx, run); err != nil {
+		logf(ctx, "ERROR", "error recording import run: %v", err)
+	}
+	return run, err
+}
+
+// Limits on the participants deleted by an unattended import. Import
+// deletes participants missing from the export along with their check-ins
+// and notes, so a truncated export is refused. Run the import from the
+// dashboard to apply a larger change.
+const (
+	unattendedDeleteLimit   = 20
+	unattendedDeletePercent = 10
+)
+
+// checkUnattendedImport returns an error if the fetched export has no
+// participants or if importing it would delete more participants than the
+// unattended limits allow.
+func (a *application) checkUnattendedImport(ctx context.Context, participants []*model.Participant) error {
+	if len(participants) == 0 {
+		return errors.New("import refused, the export has no participants")
+	}
+	existing, deleted, err := a.store.ImportDeletions(ctx, participants)
+	if err != nil {
+		return err
+	}
+	if len(deleted) > unattendedDeleteLimit || len(deleted)*100 > existing*unattendedDeletePercent {
+		return fmt.Errorf("import refused, the import would delete %d of %d participants; the limit for scheduled imports is %d or %d%%",
+			len(deleted), existing, unattendedDeleteLimit, unattendedDeletePercent)
+	}
+	return nil
+}
+
+// parseHeaderLines parses HTTP headers in "Name: value" format, one per line.
+func parseHeaderLines(s string) (http.Header, error) {
+	header := make(http.Header)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid header line %q", line)
+		}
+		header.Add(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+	}
+	return header, nil
+}
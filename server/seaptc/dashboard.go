@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"crypto/rand"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,31 +17,51 @@ import (
 	"golang.org/x/sync/errgroup"
 	"rsc.io/qr"
 
-	"github.com/seaptc/server/dk"
+	"github.com/seaptc/server/certificate"
+	"github.com/seaptc/server/conflict"
+	"github.com/seaptc/server/importer"
 	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+	"github.com/seaptc/server/printout"
+	"github.com/seaptc/server/rooms"
 	"github.com/seaptc/server/sheet"
 	"github.com/seaptc/server/store"
+	"github.com/seaptc/server/xlsx"
 )
 
 type dashboardService struct {
 	*application
 	templates struct {
-		Admin        *templates.Template `html:"dashboard/admin.html dashboard/root.html common.html"`
-		Class        *templates.Template `html:"dashboard/class.html dashboard/root.html common.html"`
-		Classes      *templates.Template `html:"dashboard/classes.html dashboard/root.html common.html"`
-		Conference   *templates.Template `html:"dashboard/conference.html dashboard/root.html common.html"`
-		Error        *templates.Template `html:"dashboard/error.html dashboard/root.html common.html"`
-		EvalCode     *templates.Template `html:"dashboard/evalCode.html dashboard/root.html common.html"`
-		Evaluation   *templates.Template `html:"dashboard/evaluation.html dashboard/root.html common.html"`
-		Evaluations  *templates.Template `html:"dashboard/evaluations.html dashboard/root.html common.html"`
-		Index        *templates.Template `html:"dashboard/index.html dashboard/root.html common.html"`
-		Instructors  *templates.Template `html:"dashboard/instructors.html dashboard/root.html common.html"`
-		LunchCount   *templates.Template `html:"dashboard/lunchCount.html dashboard/root.html common.html"`
-		LunchList    *templates.Template `html:"dashboard/lunchList.html dashboard/root.html common.html"`
-		Participant  *templates.Template `html:"dashboard/participant.html dashboard/root.html common.html"`
-		Participants *templates.Template `html:"dashboard/participants.html dashboard/root.html common.html"`
-		Reprint      *templates.Template `html:"dashboard/reprint.html dashboard/root.html common.html"`
-		Report       *templates.Template `html:"dashboard/report.html dashboard/root.html common.html"`
+		Admin           *templates.Template `html:"dashboard/admin.html dashboard/root.html common.html"`
+		CatererOrder    *templates.Template `html:"dashboard/catererOrder.html dashboard/root.html common.html"`
+		ClassChanges    *templates.Template `html:"dashboard/classChanges.html dashboard/root.html common.html"`
+		Class           *templates.Template `html:"dashboard/class.html dashboard/root.html common.html"`
+		Classes         *templates.Template `html:"dashboard/classes.html dashboard/root.html common.html"`
+		Conference      *templates.Template `html:"dashboard/conference.html dashboard/root.html common.html"`
+		Conflicts       *templates.Template `html:"dashboard/conflicts.html dashboard/root.html common.html"`
+		Duplicates      *templates.Template `html:"dashboard/duplicates.html dashboard/root.html common.html"`
+		Error           *templates.Template `html:"dashboard/error.html dashboard/root.html common.html"`
+		EvalCode        *templates.Template `html:"dashboard/evalCode.html dashboard/root.html common.html"`
+		Evaluation      *templates.Template `html:"dashboard/evaluation.html dashboard/root.html common.html"`
+		Evaluations     *templates.Template `html:"dashboard/evaluations.html dashboard/root.html common.html"`
+		Index           *templates.Template `html:"dashboard/index.html dashboard/root.html common.html"`
+		Instructors     *templates.Template `html:"dashboard/instructors.html dashboard/root.html common.html"`
+		LunchCount      *templates.Template `html:"dashboard/lunchCount.html dashboard/root.html common.html"`
+		LunchList       *templates.Template `html:"dashboard/lunchList.html dashboard/root.html common.html"`
+		LunchSeating    *templates.Template `html:"dashboard/lunchSeating.html dashboard/root.html common.html"`
+		Lunches         *templates.Template `html:"dashboard/lunches.html dashboard/root.html common.html"`
+		Overrides       *templates.Template `html:"dashboard/overrides.html dashboard/root.html common.html"`
+		Participant     *templates.Template `html:"dashboard/participant.html dashboard/root.html common.html"`
+		Participants    *templates.Template `html:"dashboard/participants.html dashboard/root.html common.html"`
+		Participation   *templates.Template `html:"dashboard/participation.html dashboard/root.html common.html"`
+		PrintJobs       *templates.Template `html:"dashboard/printJobs.html dashboard/root.html common.html"`
+		Reprint         *templates.Template `html:"dashboard/reprint.html dashboard/root.html common.html"`
+		Report          *templates.Template `html:"dashboard/report.html dashboard/root.html common.html"`
+		Rooms           *templates.Template `html:"dashboard/rooms.html dashboard/root.html common.html"`
+		Timeline        *templates.Template `html:"dashboard/timeline.html dashboard/root.html common.html"`
+		Webhooks        *templates.Template `html:"dashboard/webhooks.html dashboard/root.html common.html"`
+		ValidateClasses *templates.Template `html:"dashboard/validateClasses.html dashboard/root.html common.html"`
+		WalkIn          *templates.Template `html:"dashboard/walkIn.html dashboard/root.html common.html"`
 
 		LunchStickers *templates.Template `html:"dashboard/lunchStickers.html"`
 		Form          *templates.Template `html:"dashboard/form.html blurbs.html"`
@@ -106,7 +125,7 @@ func (svc *dashboardService) Serve_dashboard(rc *requestContext) error {
 		data.Total++
 		data.Councils[p.Council]++
 		data.Types[p.Type()]++
-		if p.Council == "Chief Seattle" {
+		if p.Council == homeCouncil {
 			d := data.Districts[p.District]
 			if d == nil {
 				d = make(map[string]int)
@@ -326,6 +345,7 @@ func (svc *dashboardService) Serve_dashboard_participants_(rc *requestContext) e
 		Conference     *model.Conference
 		SessionClasses []*model.SessionClass
 		Lunch          *model.Lunch
+		CheckinTime    string
 	}{
 		Participant:    participant,
 		Conference:     conf,
@@ -333,18 +353,1185 @@ func (svc *dashboardService) Serve_dashboard_participants_(rc *requestContext) e
 		Lunch:          conf.ParticipantLunch(participant),
 	}
 
+	if !participant.CheckinTime.IsZero() {
+		data.CheckinTime = participant.CheckinTime.In(model.TimeLocation).Format("Mon Jan 2 3:04 PM")
+	}
+
 	return rc.respond(svc.templates.Participant, http.StatusOK, &data)
 }
 
-func (svc *dashboardService) Serve_dashboard_uploadRegistrations(rc *requestContext) error {
-	if rc.request.Method != "POST" {
-		return httperror.ErrMethodNotAllowed
+func (svc *dashboardService) Serve_dashboard_overrides(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	if rc.request.Method == "POST" {
+		id := rc.request.FormValue("id")
+		field := rc.request.FormValue("field")
+		action := rc.request.FormValue("action")
+		if action != "keep" && action != "revert" {
+			return &httperror.Error{Status: http.StatusBadRequest, Message: "Unknown action."}
+		}
+		var name string
+		err := svc.store.UpdateParticipant(rc.ctx, id, func(p *model.Participant) error {
+			if p.Override(field) == nil {
+				return store.ErrNotFound
+			}
+			name = p.Name()
+			if action == "keep" {
+				p.KeepOverride(field)
+				return nil
+			}
+			x := *p
+			p.RemoveOverride(field)
+			p.PrintForm = p.PrintForm || !p.EqualPrintFields(&x)
+			return nil
+		})
+		switch {
+		case err == store.ErrNotFound:
+			return rc.redirect(rc.request.URL.Path, "danger", "Profile edit not found.")
+		case err != nil:
+			return err
+		case action == "keep":
+			return rc.redirect(rc.request.URL.Path, "info", "Kept edit for %s.", name)
+		default:
+			return rc.redirect(rc.request.URL.Path, "info", "Reverted edit for %s.", name)
+		}
+	}
+
+	participants, err := svc.store.GetAllParticipantsFull(rc.ctx)
+	if err != nil {
+		return err
+	}
+
+	type row struct {
+		Participant *model.Participant
+		Override    *model.ParticipantOverride
+	}
+	var data struct {
+		Conflicts []row
+		Overrides []row
+	}
+	model.SortParticipants(participants, "")
+	for _, p := range participants {
+		for i := range p.Overrides {
+			o := &p.Overrides[i]
+			if o.Conflict() {
+				data.Conflicts = append(data.Conflicts, row{p, o})
+			} else {
+				data.Overrides = append(data.Overrides, row{p, o})
+			}
+		}
+	}
+	return rc.respond(svc.templates.Overrides, http.StatusOK, &data)
+}
+
+// Serve_dashboard_classChanges shows the class change requests waiting for
+// approval.
+func (svc *dashboardService) Serve_dashboard_classChanges(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g          errgroup.Group
+		changes    []*model.ClassChange
+		classInfo  *model.ClassInfo
+		registered map[int]int
+	)
+
+	g.Go(func() error {
+		var err error
+		changes, err = svc.store.GetClassChanges(rc.ctx, model.ClassChangePending)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		registered, err = svc.store.GetClassParticipantCounts(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	if rc.request.Method == "POST" {
+		return svc.decideClassChange(rc, classInfo, registered)
+	}
+
+	type request struct {
+		*model.ClassChange
+		Drop     *model.Class
+		Add      *model.Class
+		Problems []string
+	}
+	var data struct {
+		Requests []*request
+	}
+
+	ids := make([]string, len(changes))
+	for i, cc := range changes {
+		ids[i] = cc.ParticipantID
+	}
+	participants, err := svc.store.GetParticipantsByID(rc.ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*model.Participant)
+	for _, p := range participants {
+		byID[p.ID] = p
+	}
+
+	for _, cc := range changes {
+		p := byID[cc.ParticipantID]
+		if p == nil {
+			p = &model.Participant{}
+		}
+		data.Requests = append(data.Requests, &request{
+			ClassChange: cc,
+			Drop:        classInfo.LookupNumber(cc.Drop),
+			Add:         classInfo.LookupNumber(cc.Add),
+			Problems:    classInfo.CheckClassChange(p, cc, registered),
+		})
+	}
+	return rc.respond(svc.templates.ClassChanges, http.StatusOK, &data)
+}
+
+func (svc *dashboardService) decideClassChange(rc *requestContext, classInfo *model.ClassInfo, registered map[int]int) error {
+	id, _ := strconv.ParseInt(rc.request.FormValue("id"), 10, 64)
+	now := time.Now()
+	status := model.ClassChangeDenied
+	if rc.request.FormValue("action") == "approve" {
+		status = model.ClassChangeApproved
+	}
+	force := rc.request.FormValue("force") != ""
+
+	// The participant is changed in the same transaction as the request
+	// status so that a request decided twice is applied once.
+	cc, err := svc.store.DecideClassChange(rc.ctx, id, func(cc *model.ClassChange, p *model.Participant) error {
+		if status == model.ClassChangeApproved {
+			if p == nil {
+				return &httperror.Error{Status: http.StatusConflict, Message: "Participant not found."}
+			}
+			if problems := classInfo.CheckClassChange(p, cc, registered); len(problems) > 0 && !force {
+				return &httperror.Error{Status: http.StatusConflict, Message: strings.Join(problems, " ")}
+			}
+			x := *p
+			p.ApplyClassChange(cc, now)
+			p.PrintForm = p.PrintForm || !p.EqualPrintFields(&x)
+		}
+		cc.Status = status
+		cc.StaffID = rc.staffID
+		cc.Reason = strings.TrimSpace(rc.request.FormValue("reason"))
+		cc.Updated = now
+		return nil
+	})
+	if e, ok := err.(*httperror.Error); ok {
+		return rc.redirect(rc.request.URL.Path, "danger", "Request from %s not approved: %s", cc.ParticipantName, e.Message)
+	}
+	switch {
+	case err == store.ErrNotFound:
+		return httperror.ErrNotFound
+	case err == store.ErrClassChangeDecided:
+		return rc.redirect(rc.request.URL.Path, "info", "The request from %s was already %s.", cc.ParticipantName, cc.Status)
+	case err != nil:
+		return err
+	}
+	return rc.redirect(rc.request.URL.Path, "info", "Request from %s %s.", cc.ParticipantName, status)
+}
+
+// Serve_dashboard_duplicates lists participants that may be the same person
+// and merges duplicates.
+func (svc *dashboardService) Serve_dashboard_duplicates(rc *requestContext) error {
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	if rc.request.Method == "POST" {
+		survivorID := rc.request.FormValue("survivor")
+		duplicateID := rc.request.FormValue("duplicate")
+		err := svc.store.MergeParticipants(rc.ctx, survivorID, duplicateID)
+		switch {
+		case err == store.ErrNotFound:
+			return rc.redirect(rc.request.URL.Path, "danger", "Participant not found.")
+		case err != nil:
+			return err
+		}
+		svc.notifyParticipantImport(rc.ctx, &store.ParticipantImport{Updated: []string{survivorID}, Deleted: []string{duplicateID}})
+		return rc.redirect(rc.request.URL.Path, "info", "Participants merged.")
+	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		status       map[string]*store.EvaluationStatus
+	)
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipantsFull(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		status, err = svc.store.GetAllEvaluationStatus(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	data := struct {
+		Duplicates  []*model.DuplicateParticipants
+		Evaluations func(p *model.Participant) int
+	}{
+		Duplicates: model.FindDuplicateParticipants(participants),
+		Evaluations: func(p *model.Participant) int {
+			s := status[p.ID]
+			if s == nil {
+				return 0
+			}
+			n := 0
+			if s.Conference {
+				n++
+			}
+			for _, c := range s.ClassNumbers {
+				if c != 0 {
+					n++
+				}
+			}
+			return n
+		},
+	}
+	return rc.respond(svc.templates.Duplicates, http.StatusOK, &data)
+}
+
+// walkInUnitTypes are the unit types offered on the walk-in form.
+var walkInUnitTypes = []string{"Pack", "Troop", "Crew", "Ship"}
+
+// Serve_dashboard_walkIn adds a provisional participant for a walk-in. The
+// participant is replaced by the registration record when the walk-in's
+// Doubleknot registration is imported.
+func (svc *dashboardService) Serve_dashboard_walkIn(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g          errgroup.Group
+		conf       *model.Conference
+		classInfo  *model.ClassInfo
+		registered map[int]int
+	)
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetCachedConference(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		registered, err = svc.store.GetClassParticipantCounts(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	rc.request.ParseForm()
+	data := struct {
+		Form        url.Values
+		Invalid     map[string]string
+		UnitTypes   []string
+		DietaryTags []*model.DietaryTag
+		Sessions    [][]*model.Class
+	}{
+		Form:        rc.request.Form,
+		Invalid:     make(map[string]string),
+		UnitTypes:   walkInUnitTypes,
+		DietaryTags: conf.DietaryTagList(),
+		Sessions:    make([][]*model.Class, model.NumSession),
+	}
+
+	// Offer the classes that are not full by starting session.
+	for _, c := range classInfo.Classes() {
+		start, end := c.StartEnd()
+		if !model.IsValidClassNumber(c.Number) || end >= model.NumSession ||
+			c.Capacity < 0 || (c.Capacity > 0 && registered[c.Number] >= c.Capacity) {
+			continue
+		}
+		data.Sessions[start] = append(data.Sessions[start], c)
+	}
+
+	if rc.request.Method != "POST" {
+		return rc.respond(svc.templates.WalkIn, http.StatusOK, &data)
+	}
+
+	p := &model.Participant{
+		FirstName:  strings.TrimSpace(data.Form.Get("firstName")),
+		LastName:   strings.TrimSpace(data.Form.Get("lastName")),
+		Nickname:   strings.TrimSpace(data.Form.Get("nickname")),
+		Email:      strings.TrimSpace(data.Form.Get("email")),
+		Phone:      strings.TrimSpace(data.Form.Get("phone")),
+		Youth:      data.Form.Get("youth") != "",
+		UnitType:   data.Form.Get("unitType"),
+		UnitNumber: strings.TrimSpace(data.Form.Get("unitNumber")),
+		Council:    strings.TrimSpace(data.Form.Get("council")),
+		District:   strings.TrimSpace(data.Form.Get("district")),
+		Allergies:  strings.TrimSpace(data.Form.Get("allergies")),
+	}
+	if p.Youth {
+		p.RegisteredByEmail = p.Email
+	}
+	var tags []string
+	for _, t := range data.DietaryTags {
+		if containsFold(data.Form["dietary"], t.Name) {
+			tags = append(tags, t.Name)
+		}
+	}
+	p.SetDietaryTags(tags)
+
+	if p.FirstName == "" {
+		data.Invalid["firstName"] = "First name is required."
+	}
+	if p.LastName == "" {
+		data.Invalid["lastName"] = "Last name is required."
+	}
+	if p.Email == "" {
+		data.Invalid["email"] = "Email is required to match the registration."
+	}
+
+	for session, classes := range data.Sessions {
+		name := fmt.Sprintf("class%d", session)
+		n, _ := strconv.Atoi(data.Form.Get(name))
+		if n == 0 {
+			continue
+		}
+		found := false
+		for _, c := range classes {
+			found = found || c.Number == n
+		}
+		if !found {
+			data.Invalid[name] = fmt.Sprintf("Class %d is full.", n)
+			continue
+		}
+		p.Classes = append(p.Classes, n)
+	}
+	if overlaps := classInfo.ScheduleOverlaps(p.Classes); len(overlaps) > 0 {
+		data.Invalid["classes"] = strings.Join(overlaps, " ")
+	}
+
+	if len(data.Invalid) > 0 {
+		return rc.respond(svc.templates.WalkIn, http.StatusOK, &data)
+	}
+
+	// Send staff to the registration record if the walk-in already
+	// registered.
+	participants, err := svc.store.GetAllParticipantsFull(rc.ctx)
+	if err != nil {
+		return err
+	}
+	for _, x := range participants {
+		if !x.Provisional && x.SameNameAndEmail(p) {
+			return rc.redirect("/dashboard/participants/"+x.ID, "info", "%s is already registered.", x.Name())
+		}
+	}
+
+	p.CheckinTime = time.Now()
+	err = svc.store.AddProvisionalParticipant(rc.ctx, p)
+	switch {
+	case err == store.ErrParticipantExists:
+		data.Invalid["lastName"] = "A walk-in with this name was already added."
+		return rc.respond(svc.templates.WalkIn, http.StatusOK, &data)
+	case err != nil:
+		return err
+	}
+	svc.notifyParticipantImport(rc.ctx, &store.ParticipantImport{Added: []string{p.ID}})
+
+	return rc.redirect("/dashboard/participants/"+p.ID, "info", "%s added with login code %s. The form is queued for printing.",
+		p.Name(), p.LoginCode)
+}
+
+func (svc *dashboardService) Serve_dashboard_checkin(rc *requestContext) error {
+	if rc.request.Method != "POST" {
+		return httperror.ErrMethodNotAllowed
+	}
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	id := rc.request.FormValue("id")
+	now := time.Now().In(model.TimeLocation)
+	t, err := svc.store.CheckinParticipant(rc.ctx, id, now)
+	switch {
+	case err == store.ErrNotFound:
+		return httperror.ErrNotFound
+	case err != nil:
+		return err
+	}
+
+	participant, err := svc.store.GetParticipant(rc.ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// CheckinParticipant returns the earlier time if the participant was
+	// already checked in. Notify on the first check-in only.
+	if t.Equal(now) {
+		data := struct {
+			ParticipantID string    `json:"participantID"`
+			Name          string    `json:"name"`
+			Time          time.Time `json:"time"`
+			Staff         string    `json:"staff"`
+		}{participant.ID, participant.Name(), t, rc.staffID}
+		svc.notify(rc.ctx, newEvents(rc.ctx, model.CheckinEvent, &data)...)
+	}
+
+	return rc.redirect("/dashboard/participants/"+id, "info", "%s checked in at %s.",
+		participant.Name(), t.In(model.TimeLocation).Format("3:04 PM"))
+}
+
+func (svc *dashboardService) Serve_dashboard_uploadRegistrations(rc *requestContext) error {
+	if rc.request.Method != "POST" {
+		return httperror.ErrMethodNotAllowed
+	}
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	f, fh, err := rc.request.FormFile("file")
+	if err == http.ErrMissingFile {
+		return &httperror.Error{Status: 400, Message: "Export file not uploaded"}
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	participants, err := svc.parseRegistrations(rc.ctx, f, fh.Filename, rc.request.FormValue("profile"))
+	if err != nil {
+		return err
+	}
+
+	result, err := svc.store.ImportParticipants(rc.ctx, participants)
+	if err != nil {
+		return err
+	}
+	svc.notifyParticipantImport(rc.ctx, result)
+	svc.recordRegistrationSnapshot(rc.ctx)
+
+	return rc.redirect("/dashboard/admin", "info", "Import %d records; %s", len(participants), result.Summary)
+}
+
+func (svc *dashboardService) Serve_dashboard_refreshClasses(rc *requestContext) error {
+	if rc.request.Method != "POST" {
+		return httperror.ErrMethodNotAllowed
+	}
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g                  errgroup.Group
+		rows               [][]string
+		suggestedSchedules []*model.SuggestedSchedule
+		src                = sheet.NewSource(svc.config)
+	)
+
+	g.Go(func() error {
+		var err error
+		rows, err = src.ClassRows(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		suggestedSchedules, err = sheet.GetSuggestedSchedules(rc.ctx, src)
+		if err == sheet.ErrNoSuggestedSchedules {
+			suggestedSchedules = nil
+			err = nil
+		}
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return svc.importClasses(rc, rows, suggestedSchedules, "sheet", "")
+}
+
+// Serve_dashboard_uploadClasses loads classes and optionally suggested
+// schedules from uploaded copies of the planning spreadsheet.
+func (svc *dashboardService) Serve_dashboard_uploadClasses(rc *requestContext) error {
+	if rc.request.Method != "POST" {
+		return httperror.ErrMethodNotAllowed
+	}
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	f, fh, err := rc.request.FormFile("file")
+	if err == http.ErrMissingFile {
+		return &httperror.Error{Status: 400, Message: "Classes file not uploaded"}
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	source := fh.Filename
+	rows, err := sheet.ReadRows(fh.Filename, f)
+	if err != nil {
+		return &httperror.Error{Status: 400, Message: fmt.Sprintf("Error reading classes file: %v", err), Err: err}
+	}
+
+	var suggestedSchedules []*model.SuggestedSchedule
+	sf, sfh, err := rc.request.FormFile("suggestedSchedulesFile")
+	switch {
+	case err == http.ErrMissingFile:
+		// Keep current suggested schedules.
+	case err != nil:
+		return err
+	default:
+		defer sf.Close()
+		suggestedSchedules, err = sheet.ParseSuggestedSchedules(sfh.Filename, sf)
+		if err != nil {
+			return &httperror.Error{Status: 400, Message: fmt.Sprintf("Error reading suggested schedules file: %v", err), Err: err}
+		}
+	}
+
+	return svc.importClasses(rc, rows, suggestedSchedules, source, "")
+}
+
+// importClasses validates and imports classes from the rows of the
+// planning spreadsheet. The validation report is shown instead if the
+// spreadsheet has errors. Suggested schedules are not modified if nil. The
+// note is appended to the flash message.
+func (svc *dashboardService) importClasses(rc *requestContext, rows [][]string, suggestedSchedules []*model.SuggestedSchedule, source string, note string) error {
+	classes, report, err := svc.validateClasses(rc, rows)
+	if err != nil {
+		return err
+	}
+	if report.Errors() > 0 {
+		return svc.respondValidation(rc, report, source)
+	}
+
+	changed, err := svc.store.ImportClasses(rc.ctx, classes)
+	if err != nil {
+		return err
+	}
+	svc.notifyClassImport(rc.ctx, classes, changed)
+
+	if suggestedSchedules != nil {
+		err = svc.store.SetSuggestedSchedules(rc.ctx, suggestedSchedules)
+		if err != nil {
+			return err
+		}
+	}
+
+	if n := report.Warnings(); n > 0 {
+		return rc.redirect("/dashboard/classes", "info", "%d classes loaded from %s, %d modified, %d warnings (see Check classes on the admin page)%s", len(classes), source, len(changed), n, note)
+	}
+	return rc.redirect("/dashboard/classes", "info", "%d classes loaded from %s, %d modified%s", len(classes), source, len(changed), note)
+}
+
+func (svc *dashboardService) validateClasses(rc *requestContext, rows [][]string) ([]*model.Class, *sheet.Report, error) {
+	registered, err := svc.store.GetClassParticipantCounts(rc.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	classes, report, err := sheet.ValidateClasses(rows, registered)
+	if err != nil {
+		return nil, nil, &httperror.Error{Status: http.StatusBadRequest, Message: fmt.Sprintf("Error reading classes: %v", err), Err: err}
+	}
+	return classes, report, nil
+}
+
+func (svc *dashboardService) respondValidation(rc *requestContext, report *sheet.Report, source string) error {
+	return rc.respond(svc.templates.ValidateClasses, http.StatusOK, &struct {
+		Report *sheet.Report
+		Source string
+	}{
+		report,
+		source,
+	})
+}
+
+// Serve_dashboard_validateClasses shows the validation report for the
+// planning spreadsheet without importing the classes.
+func (svc *dashboardService) Serve_dashboard_validateClasses(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+	rows, err := sheet.NewSource(svc.config).ClassRows(rc.ctx)
+	if err != nil {
+		return err
+	}
+	_, report, err := svc.validateClasses(rc, rows)
+	if err != nil {
+		return err
+	}
+	return svc.respondValidation(rc, report, "sheet")
+}
+
+func (svc *dashboardService) Serve_dashboard_conference(rc *requestContext) error {
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+	conf, err := svc.store.GetConference(rc.ctx)
+	if err != nil {
+		return err
+	}
+
+	rc.request.ParseForm()
+	data := struct {
+		Form       url.Values
+		Invalid    map[string]string
+		Conference *model.Conference
+		Programs   []*model.ProgramDescription
+		FieldNames []string
+
+		DefaultLabelTemplates []*model.LabelTemplate
+		DefaultDietaryTags    []*model.DietaryTag
+	}{
+		Form:       rc.request.Form,
+		Invalid:    make(map[string]string),
+		Conference: conf,
+		Programs:   model.ProgramDescriptions,
+		FieldNames: importer.FieldNames(),
+
+		DefaultLabelTemplates: model.DefaultLabelTemplates,
+		DefaultDietaryTags:    model.DefaultDietaryTags,
+	}
+
+	if rc.request.Method != "POST" {
+		p, _ := json.MarshalIndent(conf.ImportProfiles, "", "  ")
+		data.Form.Set("importProfiles", string(p))
+		p, _ = json.MarshalIndent(conf.Rooms, "", "  ")
+		data.Form.Set("rooms", string(p))
+		p, _ = json.MarshalIndent(conf.CertificateTemplates, "", "  ")
+		data.Form.Set("certificateTemplates", string(p))
+		p, _ = json.MarshalIndent(conf.TrainingCourses, "", "  ")
+		data.Form.Set("trainingCourses", string(p))
+		p, _ = json.MarshalIndent(conf.LabelTemplates, "", "  ")
+		data.Form.Set("labelTemplates", string(p))
+		p, _ = json.MarshalIndent(conf.DietaryTagList(), "", "  ")
+		data.Form.Set("dietaryTags", string(p))
+		data.Form.Set("allergyColumns", strings.Join(conf.AllergyColumns, "\n"))
+		return rc.respond(svc.templates.Conference, http.StatusOK, &data)
+	}
+
+	conf.ImportProfiles = nil
+	if err := json.Unmarshal([]byte(data.Form.Get("importProfiles")), &conf.ImportProfiles); err != nil {
+		data.Invalid["importProfiles"] = err.Error()
+	} else if err := importer.CheckProfiles(conf.ImportProfiles); err != nil {
+		data.Invalid["importProfiles"] = err.Error()
+	}
+
+	conf.Rooms = nil
+	if err := json.Unmarshal([]byte(data.Form.Get("rooms")), &conf.Rooms); err != nil {
+		data.Invalid["rooms"] = err.Error()
+	} else if err := model.CheckRooms(conf.Rooms); err != nil {
+		data.Invalid["rooms"] = err.Error()
+	}
+
+	conf.CertificateTemplates = nil
+	if err := json.Unmarshal([]byte(data.Form.Get("certificateTemplates")), &conf.CertificateTemplates); err != nil {
+		data.Invalid["certificateTemplates"] = err.Error()
+	} else {
+		for _, t := range conf.CertificateTemplates {
+			if err := certificate.CheckTemplate(t); err != nil {
+				data.Invalid["certificateTemplates"] = err.Error()
+			}
+		}
+	}
+
+	conf.TrainingCourses = nil
+	if err := json.Unmarshal([]byte(data.Form.Get("trainingCourses")), &conf.TrainingCourses); err != nil {
+		data.Invalid["trainingCourses"] = err.Error()
+	}
+
+	conf.LabelTemplates = nil
+	if err := json.Unmarshal([]byte(data.Form.Get("labelTemplates")), &conf.LabelTemplates); err != nil {
+		data.Invalid["labelTemplates"] = err.Error()
+	} else {
+		for _, t := range conf.LabelTemplates {
+			if err := t.Check(); err != nil {
+				data.Invalid["labelTemplates"] = err.Error()
+			}
+		}
+	}
+
+	conf.DietaryTags = nil
+	if strings.TrimSpace(data.Form.Get("dietaryTags")) == "" {
+		// Use the default tags.
+	} else if err := json.Unmarshal([]byte(data.Form.Get("dietaryTags")), &conf.DietaryTags); err != nil {
+		data.Invalid["dietaryTags"] = err.Error()
+	} else if problems := model.CheckDietaryTags(conf.DietaryTags); len(problems) > 0 {
+		data.Invalid["dietaryTags"] = strings.Join(problems, " ")
+	}
+
+	conf.AllergyColumns = nil
+	for _, line := range strings.Split(data.Form.Get("allergyColumns"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			conf.AllergyColumns = append(conf.AllergyColumns, line)
+		}
+	}
+
+	if s := strings.TrimSpace(data.Form.Get("lunchBuffer")); s == "" {
+		conf.LunchBuffer = 0
+	} else if n, err := strconv.Atoi(s); err != nil || n < 0 {
+		data.Invalid["lunchBuffer"] = "Enter a percentage."
+	} else {
+		conf.LunchBuffer = n
+	}
+
+	conf.ClassChanges = data.Form.Get("classChanges") != ""
+	conf.RegistrationURL = data.Form.Get("registrationURL")
+	conf.CatalogStatusMessage = data.Form.Get("catalogStatusMessage")
+	conf.NoClassDescription = data.Form.Get("noClassDescription")
+	conf.OABanquetDescription = data.Form.Get("oaBanquetDescription")
+	conf.OpeningLocation = data.Form.Get("openingLocation")
+	conf.OABanquetLocation = data.Form.Get("oaBanquetLocation")
+	conf.RegistrationExportURL = strings.TrimSpace(data.Form.Get("registrationExportURL"))
+	conf.RegistrationExportHeaders = data.Form.Get("registrationExportHeaders")
+	if _, err := parseHeaderLines(conf.RegistrationExportHeaders); err != nil {
+		data.Invalid["registrationExportHeaders"] = err.Error()
+	}
+
+	conf.StaffIDs = data.Form.Get("staffIDs")
+	if len(data.Invalid) > 0 {
+		return rc.respond(svc.templates.Conference, http.StatusOK, &data)
+	}
+
+	err = svc.store.SetConference(rc.ctx, conf)
+	if err != nil {
+		return err
+	}
+
+	return rc.redirect(rc.request.URL.Path, "info", "Conference updated.")
+}
+
+func (svc *dashboardService) Serve_dashboard_webhooks(rc *requestContext) error {
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	if rc.request.Method == "POST" {
+		switch rc.request.FormValue("action") {
+		case "delete":
+			id, _ := strconv.ParseInt(rc.request.FormValue("id"), 10, 64)
+			if err := svc.store.DeleteWebhook(rc.ctx, id); err != nil {
+				return err
+			}
+			return rc.redirect(rc.request.URL.Path, "info", "Webhook deleted.")
+		case "add":
+			u, err := url.Parse(strings.TrimSpace(rc.request.FormValue("url")))
+			if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return rc.redirect(rc.request.URL.Path, "danger", "Invalid webhook URL.")
+			}
+			var b [16]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				return err
+			}
+			hook := &model.Webhook{
+				URL:     u.String(),
+				Secret:  fmt.Sprintf("%x", b[:]),
+				Created: time.Now(),
+			}
+			for _, e := range model.WebhookEvents {
+				if rc.request.FormValue(e) != "" {
+					hook.Events = append(hook.Events, e)
+				}
+			}
+			if err := svc.store.AddWebhook(rc.ctx, hook); err != nil {
+				return err
+			}
+			return rc.redirect(rc.request.URL.Path, "info", "Webhook added for %s.", hook.URL)
+		default:
+			return &httperror.Error{Status: http.StatusBadRequest, Message: "Unknown action."}
+		}
+	}
+
+	var data struct {
+		Webhooks   []*model.Webhook
+		Deliveries []*model.WebhookDelivery
+		Events     []string
+	}
+	data.Events = model.WebhookEvents
+
+	var g errgroup.Group
+	g.Go(func() error {
+		var err error
+		data.Webhooks, err = svc.store.GetWebhooks(rc.ctx)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		data.Deliveries, err = svc.store.GetWebhookDeliveries(rc.ctx, 100)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return rc.respond(svc.templates.Webhooks, http.StatusOK, &data)
+}
+
+func (svc *dashboardService) Serve_dashboard_instructors(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+	data := struct {
+	}{}
+	return rc.respond(svc.templates.Instructors, http.StatusOK, &data)
+}
+
+// Serve_dashboard_conflicts lists room and instructor conflicts between
+// classes and participants who are registered for a class in a session
+// where they are teaching.
+func (svc *dashboardService) Serve_dashboard_conflicts(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		classInfo    *model.ClassInfo
+	)
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipants(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	data := struct {
+		Classes      []*conflict.Conflict
+		Participants []*conflict.Conflict
+	}{
+		conflict.Classes(classInfo),
+		conflict.Participants(classInfo, participants),
+	}
+	return rc.respond(svc.templates.Conflicts, http.StatusOK, &data)
+}
+
+// Serve_dashboard_rooms proposes room assignments using the room inventory
+// and registration counts.
+func (svc *dashboardService) Serve_dashboard_rooms(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g          errgroup.Group
+		classes    []*model.Class
+		conf       *model.Conference
+		registered map[int]int
+	)
+
+	g.Go(func() error {
+		var err error
+		classes, err = svc.store.GetAllClassesFull(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetConference(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		registered, err = svc.store.GetClassParticipantCounts(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	headroom := 10
+	if s := rc.request.FormValue("headroom"); s != "" {
+		var err error
+		headroom, err = strconv.Atoi(s)
+		if err != nil || headroom < 0 {
+			return &httperror.Error{Status: http.StatusBadRequest, Message: "Invalid headroom"}
+		}
+	}
+
+	model.SortClasses(classes, "")
+	assignments := rooms.Assign(classes, conf.Rooms, registered, headroom)
+
+	if format := rc.request.FormValue("format"); format != "" {
+		t := newExportTable("rooms",
+			col("class", xlsx.Number),
+			col("title", xlsx.String),
+			col("length", xlsx.Number),
+			col("registered", xlsx.Number),
+			col("demand", xlsx.Number),
+			col("currentLocation", xlsx.String),
+			col("location", xlsx.String),
+			col("roomCapacity", xlsx.Number),
+			col("changed", xlsx.Bool),
+			col("overflow", xlsx.Bool))
+		for _, a := range assignments {
+			var capacity interface{}
+			if a.Room != nil && !a.Fixed {
+				capacity = a.Room.Capacity
+			}
+			t.add(a.Class.Number, a.Class.Title, a.Class.Length, a.Registered, a.Demand,
+				a.Class.Location, a.Location(), capacity, a.Changed(), a.Overflow())
+		}
+		return rc.writeExport(t)
+	}
+
+	data := struct {
+		Assignments []*rooms.Assignment
+		Headroom    int
+		Rooms       int
+		Changed     int
+		Overflow    int
+	}{
+		Assignments: assignments,
+		Headroom:    headroom,
+		Rooms:       len(conf.Rooms),
+	}
+	for _, a := range assignments {
+		if a.Changed() {
+			data.Changed++
+		}
+		if a.Overflow() {
+			data.Overflow++
+		}
+	}
+	return rc.respond(svc.templates.Rooms, http.StatusOK, &data)
+}
+
+// Serve_dashboard_timeline shows registrations over time.
+func (svc *dashboardService) Serve_dashboard_timeline(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		snapshots    []*model.RegistrationSnapshot
+		classes      []*model.Class
+	)
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipantsFull(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		snapshots, err = svc.store.GetRegistrationSnapshots(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classes, err = svc.store.GetAllClasses(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	columns := timelineColumns()
+	days := cumulativeRegistrations(participants)
+
+	if rc.request.FormValue("format") != "" {
+		xcolumns := []xlsx.Column{col("date", xlsx.String)}
+		for _, name := range columns {
+			xcolumns = append(xcolumns, col(name, xlsx.Number))
+		}
+		t := newExportTable("timeline", xcolumns...)
+		for _, d := range days {
+			row := []interface{}{d.Date}
+			for _, n := range d.Counts {
+				row = append(row, n)
+			}
+			t.add(row...)
+		}
+		return rc.writeExport(t)
+	}
+
+	model.SortClasses(classes, "")
+	data := struct {
+		Columns   []string
+		Days      []*timelineDay
+		FillDates []string
+		Fills     []*classFill
+		Years     []int
+		Weeks     []*yearOverYearWeek
+	}{
+		Columns: columns,
+		Days:    days,
+	}
+	data.FillDates, data.Fills = classFillOverTime(snapshots, svc.conferenceDate.Year(), classes)
+	data.Years, data.Weeks = yearOverYear(snapshots)
+	return rc.respond(svc.templates.Timeline, http.StatusOK, &data)
+}
+
+// Serve_dashboard_participation shows participation by zip code, district
+// and unit. A POST request loads last year's registration export so that
+// units missing this year can be listed.
+func (svc *dashboardService) Serve_dashboard_participation(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	if rc.request.Method == "POST" {
+		return svc.uploadLastYearRegistrations(rc)
+	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		snapshots    []*model.RegistrationSnapshot
+		conf         *model.Conference
+	)
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetCachedConference(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipantsFull(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		snapshots, err = svc.store.GetRegistrationSnapshots(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	units := unitCounts(participants)
+	var lastYearUnits []model.UnitCount
+	lastYear := lastYearSnapshot(snapshots, svc.conferenceDate.Year())
+	if lastYear != nil {
+		lastYearUnits = lastYear.Units
+	}
+	missing := missingUnits(homeCouncil, lastYearUnits, units)
+
+	unitTable := func(name string, units []model.UnitCount) *exportTable {
+		t := newExportTable(name,
+			col("council", xlsx.String),
+			col("district", xlsx.String),
+			col("unit", xlsx.String),
+			col("count", xlsx.Number))
+		for _, uc := range units {
+			t.add(uc.Council, uc.District, uc.Unit, uc.Count)
+		}
+		return t
+	}
+
+	switch rc.request.FormValue("table") {
+	case "zip":
+		t := newExportTable("zip",
+			col("zip", xlsx.String),
+			col("city", xlsx.String),
+			col("state", xlsx.String),
+			col("count", xlsx.Number))
+		for _, zc := range zipCounts(participants) {
+			t.add(zc.Zip, zc.City, zc.State, zc.Count)
+		}
+		return rc.writeExport(t)
+	case "district":
+		t := newExportTable("district",
+			col("council", xlsx.String),
+			col("district", xlsx.String),
+			col("units", xlsx.Number),
+			col("count", xlsx.Number))
+		for _, dc := range districtCounts(participants, units) {
+			t.add(dc.Council, dc.District, dc.Units, dc.Count)
+		}
+		return rc.writeExport(t)
+	case "unit":
+		return rc.writeExport(unitTable("units", units))
+	case "missing":
+		return rc.writeExport(unitTable("missingUnits", missing))
+	}
+
+	const maxTopUnits = 25
+	topUnits := units
+	if len(topUnits) > maxTopUnits {
+		topUnits = topUnits[:maxTopUnits]
 	}
+
+	data := struct {
+		Council        string
+		Zips           []*zipCount
+		Districts      []*districtCount
+		TopUnits       []model.UnitCount
+		Missing        []model.UnitCount
+		LastYear       *model.RegistrationSnapshot
+		ImportProfiles []*model.ImportProfile
+	}{
+		Council:        homeCouncil,
+		Zips:           zipCounts(participants),
+		Districts:      districtCounts(participants, units),
+		TopUnits:       topUnits,
+		Missing:        missing,
+		LastYear:       lastYear,
+		ImportProfiles: conf.ImportProfiles,
+	}
+	return rc.respond(svc.templates.Participation, http.StatusOK, &data)
+}
+
+// uploadLastYearRegistrations records a snapshot for last year from an
+// uploaded copy of last year's registration export. The snapshot is dated
+// one year before this year's conference.
+func (svc *dashboardService) uploadLastYearRegistrations(rc *requestContext) error {
 	if !rc.isAdmin {
 		return httperror.ErrForbidden
 	}
 
-	f, _, err := rc.request.FormFile("file")
+	f, fh, err := rc.request.FormFile("file")
 	if err == http.ErrMissingFile {
 		return &httperror.Error{Status: 400, Message: "Export file not uploaded"}
 	}
@@ -353,42 +1540,102 @@ func (svc *dashboardService) Serve_dashboard_uploadRegistrations(rc *requestCont
 	}
 	defer f.Close()
 
-	participants, err := dk.ParseCSV(f)
+	participants, err := svc.parseRegistrations(rc.ctx, f, fh.Filename, rc.request.FormValue("profile"))
 	if err != nil {
+		return &httperror.Error{Status: 400, Message: fmt.Sprintf("Error reading export file: %v", err), Err: err}
+	}
+	if len(participants) == 0 {
+		return rc.redirect(rc.request.URL.Path, "danger", "No participants in %s.", fh.Filename)
+	}
+
+	date := svc.conferenceDate.AddDate(-1, 0, 0)
+	s := newRegistrationSnapshot(participants, date, date)
+	if err := svc.store.SetRegistrationSnapshot(rc.ctx, s); err != nil {
 		return err
 	}
+	return rc.redirect(rc.request.URL.Path, "info", "Loaded %d participants from %d units for %d.", s.Total, len(s.Units), s.Year)
+}
+
+// Serve_dashboard_certificates returns training completion certificates as
+// a PDF for a participant or for all participants in a class.
+func (svc *dashboardService) Serve_dashboard_certificates(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
 
-	summary, err := svc.store.ImportParticipants(rc.ctx, participants)
+	conf, err := svc.store.GetConference(rc.ctx)
 	if err != nil {
 		return err
 	}
 
-	return rc.redirect("/dashboard/admin", "info", "Import %d records; %s", len(participants), summary)
-}
+	var (
+		name  string
+		pairs []certificatePair
+	)
+	if id := rc.request.FormValue("participant"); id != "" {
+		name = id
+		pairs, err = svc.participantCertificates(rc.ctx, id)
+	} else {
+		number, _ := strconv.Atoi(rc.request.FormValue("class"))
+		name = strconv.Itoa(number)
+		pairs, err = svc.classCertificates(rc.ctx, number)
+	}
+	if err == store.ErrNotFound {
+		return httperror.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return &httperror.Error{Status: http.StatusNotFound, Message: "No completed training classes found."}
+	}
 
-func (svc *dashboardService) Serve_dashboard_refreshClasses(rc *requestContext) error {
-	if rc.request.Method != "POST" {
-		return httperror.ErrMethodNotAllowed
+	doc := pdf.New()
+	for _, pair := range pairs {
+		err := certificate.Add(doc, conf.CertificateTemplate(pair.class.Certificate), &certificate.Data{
+			Name:        pair.participant.Name(),
+			Class:       pair.class.Title,
+			ClassNumber: pair.class.Number,
+			Council:     pair.participant.Council,
+			Date:        svc.conferenceDate.Format("January 2, 2006"),
+			Year:        svc.conferenceDate.Year(),
+		})
+		if err != nil {
+			return &httperror.Error{Status: http.StatusInternalServerError, Message: err.Error(), Err: err}
+		}
 	}
+
+	return rc.writePDF(doc, "certificates-"+name)
+}
+
+// Serve_dashboard_trainingRecords exports a row for each participant and
+// completed training course for upload to the national training system.
+func (svc *dashboardService) Serve_dashboard_trainingRecords(rc *requestContext) error {
 	if !rc.isStaff {
 		return httperror.ErrForbidden
 	}
 
 	var (
-		g                  errgroup.Group
-		classes            []*model.Class
-		suggestedSchedules []*model.SuggestedSchedule
+		g            errgroup.Group
+		conf         *model.Conference
+		participants []*model.Participant
+		evals        []*model.SessionEvaluation
 	)
 
 	g.Go(func() error {
 		var err error
-		classes, err = sheet.GetClasses(rc.ctx, svc.config)
+		conf, err = svc.store.GetConference(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipantsFull(rc.ctx)
 		return err
 	})
 
 	g.Go(func() error {
 		var err error
-		suggestedSchedules, err = sheet.GetSuggestedSchedules(rc.ctx, svc.config)
+		evals, err = svc.store.GetAllSessionEvaluations(rc.ctx)
 		return err
 	})
 
@@ -396,20 +1643,43 @@ func (svc *dashboardService) Serve_dashboard_refreshClasses(rc *requestContext)
 		return err
 	}
 
-	n, err := svc.store.ImportClasses(rc.ctx, classes)
-	if err != nil {
-		return err
+	evaluated := make(map[string]bool)
+	for _, e := range evals {
+		evaluated[fmt.Sprintf("%s/%d", e.ParticipantID, e.ClassNumber)] = true
 	}
 
-	err = svc.store.SetSuggestedSchedules(rc.ctx, suggestedSchedules)
-	if err != nil {
-		return err
+	// The first five columns match the council's training record upload
+	// format. The remaining columns help the registrar review the file
+	// before upload.
+	t := newExportTable("trainingRecords",
+		col("Member ID", xlsx.String),
+		col("First Name", xlsx.String),
+		col("Last Name", xlsx.String),
+		col("Course Code", xlsx.String),
+		col("Completion Date", xlsx.Date),
+		col("PTC Class", xlsx.Number),
+		col("Problem", xlsx.String))
+
+	model.SortParticipants(participants, "")
+	for _, p := range participants {
+		for _, n := range p.Classes {
+			code := conf.TrainingCourseCode(n)
+			if code == "" {
+				continue
+			}
+			if !completedClass(p, n, evaluated[fmt.Sprintf("%s/%d", p.ID, n)]) {
+				continue
+			}
+			t.add(p.BSANumber, p.FirstName, p.LastName, code, exportDate(svc.conferenceDate), n, p.BSANumberProblem())
+		}
 	}
 
-	return rc.redirect("/dashboard/classes", "info", "%d classes loaded from sheet, %d modified", len(classes), n)
+	return rc.writeExport(t)
 }
 
-func (svc *dashboardService) Serve_dashboard_conference(rc *requestContext) error {
+// Serve_dashboard_lunches edits the lunches and lunch rules. Changes are
+// validated and the per-lunch counts are shown before saving.
+func (svc *dashboardService) Serve_dashboard_lunches(rc *requestContext) error {
 	if !rc.isAdmin {
 		return httperror.ErrForbidden
 	}
@@ -420,55 +1690,208 @@ func (svc *dashboardService) Serve_dashboard_conference(rc *requestContext) erro
 
 	rc.request.ParseForm()
 	data := struct {
-		Form       url.Values
-		Invalid    map[string]string
-		Conference *model.Conference
-		Programs   []*model.ProgramDescription
-		Lunches    string
+		Form     url.Values
+		Invalid  map[string]string
+		Problems []string
+		Preview  *lunchPreview
 	}{
-		Form:       rc.request.Form,
-		Invalid:    make(map[string]string),
-		Conference: conf,
-		Programs:   model.ProgramDescriptions,
+		Form:    rc.request.Form,
+		Invalid: make(map[string]string),
 	}
 
 	if rc.request.Method != "POST" {
 		p, _ := json.MarshalIndent(conf.Lunches, "", "  ")
 		data.Form.Set("lunches", string(p))
-		return rc.respond(svc.templates.Conference, http.StatusOK, &data)
+		p, _ = json.MarshalIndent(conf.LunchRules, "", "  ")
+		data.Form.Set("lunchRules", string(p))
+		return rc.respond(svc.templates.Lunches, http.StatusOK, &data)
 	}
 
-	if err := json.Unmarshal([]byte(data.Form.Get("lunches")), &conf.Lunches); err != nil {
+	var proposed model.Conference
+	if err := decodeJSON(data.Form.Get("lunches"), &proposed.Lunches); err != nil {
 		data.Invalid["lunches"] = err.Error()
 	}
+	if err := decodeJSON(data.Form.Get("lunchRules"), &proposed.LunchRules); err != nil {
+		data.Invalid["lunchRules"] = err.Error()
+	}
+	if len(data.Invalid) > 0 {
+		return rc.respond(svc.templates.Lunches, http.StatusOK, &data)
+	}
 
-	conf.RegistrationURL = data.Form.Get("registrationURL")
-	conf.CatalogStatusMessage = data.Form.Get("catalogStatusMessage")
-	conf.NoClassDescription = data.Form.Get("noClassDescription")
-	conf.OABanquetDescription = data.Form.Get("oaBanquetDescription")
-	conf.OpeningLocation = data.Form.Get("openingLocation")
-	conf.OABanquetLocation = data.Form.Get("oaBanquetLocation")
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		classes      []*model.Class
+	)
 
-	conf.StaffIDs = data.Form.Get("staffIDs")
-	if len(data.Invalid) > 0 {
-		return rc.respond(svc.templates.Conference, http.StatusOK, &data)
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipants(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classes, err = svc.store.GetAllClasses(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
 	}
 
-	err = svc.store.SetConference(rc.ctx, conf)
-	if err != nil {
+	data.Problems = model.CheckLunches(proposed.Lunches, proposed.LunchRules, classes)
+	data.Preview = newLunchPreview(participants, conf, &proposed)
+	if rc.request.FormValue("action") != "save" || len(data.Problems) > 0 {
+		return rc.respond(svc.templates.Lunches, http.StatusOK, &data)
+	}
+
+	conf.Lunches = proposed.Lunches
+	conf.LunchRules = proposed.LunchRules
+	if err := svc.store.SetConference(rc.ctx, conf); err != nil {
 		return err
 	}
+	return rc.redirect(rc.request.URL.Path, "info", "Lunches updated, %d participants changed lunch.", data.Preview.Changed)
+}
 
-	return rc.redirect(rc.request.URL.Path, "info", "Conference updated.")
+func (svc *dashboardService) Serve_dashboard_lunchSeating(rc *requestContext) error {
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		conf         *model.Conference
+		classInfo    *model.ClassInfo
+	)
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipants(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetConference(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	rules, moved := conf.BalanceLunchSeatings(participants, classInfo)
+	if rc.request.Method == "POST" {
+		conf.LunchRules = rules
+		if err := svc.store.SetConference(rc.ctx, conf); err != nil {
+			return err
+		}
+		return rc.redirect(rc.request.URL.Path, "info", "Seating assigned, %d participants moved.", moved)
+	}
+
+	balanced := &model.Conference{Lunches: conf.Lunches, LunchRules: rules}
+	data := struct {
+		Current  []*model.LunchSeating
+		Balanced []*model.LunchSeating
+		Moved    int
+		Assigned int
+	}{
+		Current:  conf.LunchSeatings(participants),
+		Balanced: balanced.LunchSeatings(participants),
+		Moved:    moved,
+	}
+	for _, r := range conf.LunchRules {
+		if r.Balance {
+			data.Assigned += len(r.Participants)
+		}
+	}
+	return rc.respond(svc.templates.LunchSeating, http.StatusOK, &data)
 }
 
-func (svc *dashboardService) Serve_dashboard_instructors(rc *requestContext) error {
-	if !rc.isStaff {
+func (svc *dashboardService) Serve_dashboard_catererOrder(rc *requestContext) error {
+	if !rc.isAdmin {
 		return httperror.ErrForbidden
 	}
+
+	var (
+		g            errgroup.Group
+		participants []*model.Participant
+		conf         *model.Conference
+	)
+
+	g.Go(func() error {
+		var err error
+		participants, err = svc.store.GetAllParticipants(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetConference(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	buffer := conf.LunchBuffer
+	if s := rc.request.FormValue("buffer"); s != "" {
+		var err error
+		buffer, err = strconv.Atoi(s)
+		if err != nil || buffer < 0 {
+			return &httperror.Error{Status: http.StatusBadRequest, Message: "Invalid buffer"}
+		}
+	}
+
+	orders := newCatererOrders(conf, participants, buffer)
+
+	if format := rc.request.FormValue("format"); format != "" {
+		t := newExportTable("catererOrder",
+			col("location", xlsx.String),
+			col("seating", xlsx.Number),
+			col("item", xlsx.String),
+			col("count", xlsx.Number),
+			col("name", xlsx.String),
+			col("dietary", xlsx.String),
+			col("allergies", xlsx.String))
+		for _, o := range orders {
+			loc, seating := o.Seating.Location, o.Seating.Seating
+			t.add(loc, seating, "regular", o.Regular(), nil, nil, nil)
+			t.add(loc, seating, "buffer", o.BufferMeals(), nil, nil, nil)
+			for _, m := range o.Meals {
+				t.add(loc, seating, "meal", m.Count, nil, m.Name, nil)
+			}
+			t.add(loc, seating, "total", o.Total(), nil, nil, nil)
+			t.add(loc, seating, "staff", o.Staff, nil, nil, nil)
+			t.add(loc, seating, "instructors", o.Instructors, nil, nil, nil)
+			for _, p := range o.Special {
+				t.add(loc, seating, "special", 1, p.Name(), p.DietaryRestrictions, p.Allergies)
+			}
+		}
+		return rc.writeExport(t)
+	}
+
 	data := struct {
-	}{}
-	return rc.respond(svc.templates.Instructors, http.StatusOK, &data)
+		Orders   []*catererOrder
+		Buffer   int
+		Year     int
+		Severity func(*model.Participant) string
+	}{
+		Orders:   orders,
+		Buffer:   buffer,
+		Year:     svc.conferenceDate.Year(),
+		Severity: conf.DietarySeverity,
+	}
+	return rc.respond(svc.templates.CatererOrder, http.StatusOK, &data)
 }
 
 func (svc *dashboardService) Serve_dashboard_lunchCount(rc *requestContext) error {
@@ -495,24 +1918,19 @@ func (svc *dashboardService) Serve_dashboard_lunchCount(rc *requestContext) erro
 		return err
 	}
 
-	data := struct {
-		Lunch       map[*model.Lunch]int
-		Restriction map[string]int
-		Count       map[string]int
-		Total       int
-	}{
-		make(map[*model.Lunch]int),
-		make(map[string]int),
-		make(map[string]int),
-		0,
+	var data struct {
+		Lunches []*lunchDietary
+		Tags    []*model.DietaryTagCount
+		None    int
+		Total   int
 	}
+	data.Lunches, data.Tags = newLunchDietary(conf, participants)
 	for _, p := range participants {
-		lunch := conf.ParticipantLunch(p)
-		data.Lunch[lunch]++
-		data.Restriction[p.DietaryRestrictions]++
-		data.Count[fmt.Sprintf("%s:%s", lunch.Name, p.DietaryRestrictions)]++
-		data.Total++
+		if !p.HasDietaryNeeds() {
+			data.None++
+		}
 	}
+	data.Total = len(participants)
 	return rc.respond(svc.templates.LunchCount, http.StatusOK, &data)
 }
 
@@ -543,15 +1961,14 @@ func (svc *dashboardService) Serve_dashboard_lunchList(rc *requestContext) error
 		return err
 	}
 
-	participants = model.FilterParticipants(participants, func(p *model.Participant) bool { return p.DietaryRestrictions != "" })
-	model.SortParticipants(participants, "")
-
-	data := make(map[*model.Lunch][]*model.Participant)
-	for _, p := range participants {
-		l := conf.ParticipantLunch(p)
-		data[l] = append(data[l], p)
+	var data struct {
+		Lunches  []*lunchDietary
+		Tags     []*model.DietaryTagCount
+		Severity func(*model.Participant) string
 	}
-	return rc.respond(svc.templates.LunchList, http.StatusOK, data)
+	data.Lunches, data.Tags = newLunchDietary(conf, participants)
+	data.Severity = conf.DietarySeverity
+	return rc.respond(svc.templates.LunchList, http.StatusOK, &data)
 }
 
 func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) error {
@@ -581,7 +1998,7 @@ func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) e
 		return err
 	}
 
-	participants = model.FilterParticipants(participants, func(p *model.Participant) bool { return p.DietaryRestrictions != "" })
+	participants = model.FilterParticipants(participants, func(p *model.Participant) bool { return p.HasDietaryNeeds() })
 
 	sort.Slice(participants, func(i, j int) bool {
 		a := participants[i]
@@ -593,6 +2010,8 @@ func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) e
 			return true
 		case alunch.Name > blunch.Name:
 			return false
+		case model.DietarySeverityRank(conf.DietarySeverity(a)) != model.DietarySeverityRank(conf.DietarySeverity(b)):
+			return model.DietarySeverityRank(conf.DietarySeverity(a)) > model.DietarySeverityRank(conf.DietarySeverity(b))
 		case a.DietaryRestrictions < b.DietaryRestrictions:
 			return true
 		case a.DietaryRestrictions > b.DietaryRestrictions:
@@ -602,6 +2021,16 @@ func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) e
 		}
 	})
 
+	if rc.request.FormValue("format") == "pdf" {
+		t, err := rc.labelTemplate(conf, "lunch")
+		if err != nil {
+			return err
+		}
+		doc := pdf.New()
+		printout.LunchStickers(doc, t, participants, conf.ParticipantLunch, conf.DietarySeverity)
+		return rc.writePDF(doc, "lunchStickers")
+	}
+
 	iv := func(name string, def int) int {
 		v, _ := strconv.Atoi(rc.request.FormValue(name))
 		if v <= 0 {
@@ -618,16 +2047,18 @@ func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) e
 	}
 
 	var data = struct {
-		Rows    int
-		Columns int
-		Top     string
-		Left    string
-		Width   string
-		Height  string
-		Gutter  string
-		Font    string
-		Pages   [][][]*model.Participant
-		Lunch   interface{}
+		Rows      int
+		Columns   int
+		Top       string
+		Left      string
+		Width     string
+		Height    string
+		Gutter    string
+		Font      string
+		Pages     [][][]*model.Participant
+		Lunch     interface{}
+		Severity  interface{}
+		Templates []string
 	}{
 		iv("rows", 7),
 		iv("columns", 2),
@@ -639,6 +2070,8 @@ func (svc *dashboardService) Serve_dashboard_lunchStickers(rc *requestContext) e
 		sv("font", "16pt"),
 		nil,
 		conf.ParticipantLunch,
+		conf.DietarySeverity,
+		conf.LabelTemplateNames(),
 	}
 	for len(participants) > 0 {
 		var page [][]*model.Participant
@@ -668,13 +2101,40 @@ func (svc *dashboardService) Serve_dashboard_admin(rc *requestContext) error {
 	data := struct {
 		DevMode    bool
 		Conference *model.Conference
+		ImportRuns []*model.ImportRun
 	}{
 		DevMode:    svc.devMode,
 		Conference: conf,
 	}
+
+	if rc.isAdmin {
+		data.ImportRuns, err = svc.store.GetImportRuns(rc.ctx, 10)
+		if err != nil {
+			return err
+		}
+	}
+
 	return rc.respond(svc.templates.Admin, http.StatusOK, &data)
 }
 
+func (svc *dashboardService) Serve_dashboard_importRegistrations(rc *requestContext) error {
+	if rc.request.Method != "POST" {
+		return httperror.ErrMethodNotAllowed
+	}
+	if !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	run, err := svc.importRegistrationExport(rc.ctx, model.ImportTriggerManual)
+	switch {
+	case err == errNoRegistrationExport:
+		return rc.redirect("/dashboard/admin", "danger", "Set the registration export URL on the conference page.")
+	case err != nil:
+		return rc.redirect("/dashboard/admin", "danger", "Import failed: %v", err)
+	}
+	return rc.redirect("/dashboard/admin", "info", "Import %d records; %s", run.Count, run.Summary)
+}
+
 func (svc *dashboardService) Serve_dashboard_reprintForms(rc *requestContext) error {
 	if !rc.isStaff {
 		return httperror.ErrForbidden
@@ -701,12 +2161,14 @@ func (svc *dashboardService) Serve_dashboard_reprintForms(rc *requestContext) er
 	return rc.respond(svc.templates.Reprint, http.StatusOK, &data)
 }
 
-var formOptions = map[string]*struct {
+type formOption struct {
 	filter bool
 	auto   int
 	limit  int
 	sort   func([]*model.Participant)
-}{
+}
+
+var formOptions = map[string]*formOption{
 	"auto": {
 		filter: true,
 		auto:   60,
@@ -777,6 +2239,8 @@ func (svc *dashboardService) Serve_dashboard_forms(rc *requestContext) error {
 		return httperror.ErrForbidden
 	}
 
+	station := rc.station()
+
 	if rc.request.Method == "POST" {
 		rc.request.ParseForm()
 		ids := rc.request.Form["id"]
@@ -784,11 +2248,44 @@ func (svc *dashboardService) Serve_dashboard_forms(rc *requestContext) error {
 		if err != nil {
 			return err
 		}
+		if id, _ := strconv.ParseInt(rc.request.FormValue("job"), 10, 64); id != 0 {
+			err := svc.store.UpdatePrintJob(rc.ctx, id, func(job *model.PrintJob) error {
+				if job.Status != model.PrintJobPrinting {
+					return nil
+				}
+				job.Status = model.PrintJobPrinted
+				job.Updated = time.Now()
+				return nil
+			})
+			if err != nil && err != store.ErrNotFound {
+				return err
+			}
+		}
+	}
+
+	// A PDF download of a batch lists the participants in the batch so
+	// that the download matches the batch shown on the page.
+	if rc.request.Method != "POST" && rc.request.FormValue("format") == "pdf" {
+		rc.request.ParseForm()
+		if id, _ := strconv.ParseInt(rc.request.FormValue("job"), 10, 64); id != 0 {
+			job, err := svc.store.GetPrintJob(rc.ctx, id)
+			if err == store.ErrNotFound {
+				return httperror.ErrNotFound
+			} else if err != nil {
+				return err
+			}
+			return svc.renderForms(rc, job, 0, false, job.ParticipantIDs)
+		}
+		if ids := rc.request.Form["id"]; len(ids) > 0 {
+			return svc.renderForms(rc, nil, 0, false, ids)
+		}
 	}
 
-	options := formOptions[rc.request.FormValue("options")]
+	optionsName := rc.request.FormValue("options")
+	options := formOptions[optionsName]
 	if options == nil {
-		options = formOptions["batch"]
+		optionsName = "batch"
+		options = formOptions[optionsName]
 	}
 
 	participants, err := svc.store.GetAllParticipants(rc.ctx)
@@ -798,24 +2295,183 @@ func (svc *dashboardService) Serve_dashboard_forms(rc *requestContext) error {
 
 	auto := options.auto
 
-	options.sort(participants)
+	participants, truncated := selectForms(participants, options)
+	if truncated {
+		// Swtich to manual mode to avoid getting stuck in print-refresh loop.
+		auto = 0
+	}
+
+	ids := participantIDs(participants)
+
+	var job *model.PrintJob
+	if options.filter && len(ids) > 0 {
+		job, err = svc.printJob(rc, station, optionsName, model.PrintJobForms, "", ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	return svc.renderForms(rc, job, auto, !options.filter, ids)
+}
 
+// selectForms sorts the participants and selects the participants to print
+// using the forms page options. The function returns true if the selection
+// was truncated to the options limit.
+func selectForms(participants []*model.Participant, options *formOption) ([]*model.Participant, bool) {
+	options.sort(participants)
 	if options.filter {
 		participants = model.FilterParticipants(participants, func(p *model.Participant) bool { return p.PrintForm })
 	}
-
 	if options.limit > 0 && len(participants) > options.limit {
-		participants = participants[:options.limit]
-		// Swtich to manual mode to avoid getting stuck in print-refresh loop.
-		auto = 0
+		return participants[:options.limit], true
 	}
+	return participants, false
+}
 
+func participantIDs(participants []*model.Participant) []string {
 	ids := make([]string, len(participants))
 	for i := range participants {
 		ids[i] = participants[i].ID
 	}
+	return ids
+}
+
+// printJob returns the in flight job for the batch at the station, creating
+// a new job if the batch was not sent to the station before. Reloading the
+// forms page does not create a new job.
+func (svc *dashboardService) printJob(rc *requestContext, station, options, document, template string, ids []string) (*model.PrintJob, error) {
+	jobs, err := svc.store.GetPrintJobs(rc.ctx, 20)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.InFlight() && job.SameBatch(station, document, ids) {
+			return job, nil
+		}
+	}
+	now := time.Now()
+	job := &model.PrintJob{
+		Created:        now,
+		Updated:        now,
+		Station:        station,
+		StaffID:        rc.staffID,
+		Options:        options,
+		Document:       document,
+		Template:       template,
+		ParticipantIDs: ids,
+		Status:         model.PrintJobPrinting,
+	}
+	return job, svc.store.AddPrintJob(rc.ctx, job)
+}
+
+// printStation is a summary of the print jobs for a station.
+type printStation struct {
+	Name     string
+	InFlight []*model.PrintJob
+	Last     *model.PrintJob
+}
+
+// Serve_dashboard_printJobs shows the recent print jobs and the jobs in
+// flight at each station. Staff can mark a job as printed or failed and
+// return the participants in a job to the print queue.
+func (svc *dashboardService) Serve_dashboard_printJobs(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	if rc.request.Method == "POST" {
+		id, _ := strconv.ParseInt(rc.request.FormValue("job"), 10, 64)
+		action := rc.request.FormValue("action")
+		status := map[string]string{
+			"printed": model.PrintJobPrinted,
+			"failed":  model.PrintJobFailed,
+			"requeue": model.PrintJobRequeued,
+		}[action]
+		if status == "" {
+			return &httperror.Error{Status: http.StatusBadRequest, Message: "Unknown action."}
+		}
+		var ids []string
+		var badges bool
+		err := svc.store.UpdatePrintJob(rc.ctx, id, func(job *model.PrintJob) error {
+			job.Status = status
+			job.Updated = time.Now()
+			ids = job.ParticipantIDs
+			badges = job.IsBadges()
+			return nil
+		})
+		if err == store.ErrNotFound {
+			return httperror.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		switch action {
+		case "printed":
+			// Badge batches are selected from the forms queue. Leave the
+			// participants queued so that their forms are printed too.
+			if !badges {
+				if _, err := svc.store.SetParticipantsPrintForm(rc.ctx, ids, false); err != nil {
+					return err
+				}
+			}
+		case "requeue":
+			n, err := svc.store.SetParticipantsPrintForm(rc.ctx, ids, true)
+			if err != nil {
+				return err
+			}
+			return rc.redirect(rc.request.URL.Path, "info", "Print job %d: %d participants queued.", id, n)
+		}
+		return rc.redirect(rc.request.URL.Path, "info", "Print job %d marked %s.", id, action)
+	}
+
+	var (
+		g    errgroup.Group
+		jobs []*model.PrintJob
+		conf *model.Conference
+	)
+
+	g.Go(func() error {
+		var err error
+		jobs, err = svc.store.GetPrintJobs(rc.ctx, 100)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		conf, err = svc.store.GetCachedConference(rc.ctx)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	stationMap := make(map[string]*printStation)
+	var stations []*printStation
+	for _, job := range jobs {
+		ps := stationMap[job.Station]
+		if ps == nil {
+			// Jobs are newest first, so the first job seen is the last job
+			// for the station.
+			ps = &printStation{Name: job.Station, Last: job}
+			stationMap[job.Station] = ps
+			stations = append(stations, ps)
+		}
+		if job.InFlight() {
+			ps.InFlight = append(ps.InFlight, job)
+		}
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Name < stations[j].Name })
 
-	return svc.renderForms(rc, auto, !options.filter, ids)
+	data := struct {
+		Stations  []*printStation
+		Jobs      []*model.PrintJob
+		Templates []string
+	}{
+		stations,
+		jobs,
+		conf.LabelTemplateNames(),
+	}
+	return rc.respond(svc.templates.PrintJobs, http.StatusOK, &data)
 }
 
 func (svc *dashboardService) Serve_dashboard_forms_(rc *requestContext) error {
@@ -826,23 +2482,31 @@ func (svc *dashboardService) Serve_dashboard_forms_(rc *requestContext) error {
 	if id == "" {
 		return httperror.ErrNotFound
 	}
-	return svc.renderForms(rc, 0, true, []string{id})
+	return svc.renderForms(rc, nil, 0, true, []string{id})
 }
 
-func (svc *dashboardService) renderForms(rc *requestContext, auto int, preview bool, ids []string) error {
+// renderForms renders the forms for the participants with the given IDs.
+// The job is the print job for the forms or nil if the forms are not from
+// the print queue.
+// The forms are written as a PDF document if the format request parameter
+// is "pdf" and as an HTML page for browser printing otherwise.
+func (svc *dashboardService) renderForms(rc *requestContext, job *model.PrintJob, auto int, preview bool, ids []string) error {
 
 	var data = struct {
 		Participants   []*model.Participant
 		Conference     *model.Conference
 		Lunch          interface{}
 		SessionClasses interface{}
+		Job            *model.PrintJob
 		Auto           int
 		Preview        bool
 	}{
+		Job:     job,
 		Auto:    auto,
 		Preview: preview,
 	}
 
+	var classInfo *model.ClassInfo
 	if len(ids) > 0 {
 		var g errgroup.Group
 
@@ -861,7 +2525,7 @@ func (svc *dashboardService) renderForms(rc *requestContext, auto int, preview b
 
 		g.Go(func() error {
 			classes, err := svc.store.GetAllClasses(rc.ctx)
-			classInfo := model.NewClassInfo(classes)
+			classInfo = model.NewClassInfo(classes)
 			data.SessionClasses = classInfo.ParticipantSessionClasses
 			return err
 		})
@@ -871,42 +2535,135 @@ func (svc *dashboardService) renderForms(rc *requestContext, auto int, preview b
 		}
 	}
 
+	if rc.request.FormValue("format") == "pdf" {
+		if len(data.Participants) == 0 {
+			return &httperror.Error{Status: http.StatusNotFound, Message: "No forms to print."}
+		}
+		name := "forms"
+		if job != nil {
+			name = fmt.Sprintf("forms-%d", job.ID)
+		} else if len(data.Participants) == 1 {
+			name = "form-" + data.Participants[0].ID
+		}
+		return rc.writePDF(svc.formsPDF(data.Participants, data.Conference, classInfo), name)
+	}
+
 	return rc.respond(svc.templates.Form, http.StatusOK, &data)
 }
 
-func (svc *dashboardService) Serve_dashboard_vcard(rc *requestContext) error {
+// Serve_dashboard_badges returns sheets of name badges as a PDF. The
+// participants are given by the id request parameter, by a print job or by
+// a batch from the print queue. Admins can print badges for all
+// participants.
+func (svc *dashboardService) Serve_dashboard_badges(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
 	rc.request.ParseForm()
-	vcard := []byte("BEGIN:VCARD\r\nVERSION:4.0\r\n")
-	for name, values := range rc.request.Form {
-		value := strings.TrimSpace(values[0])
-		if value == "" {
-			continue
+	ids := rc.request.Form["id"]
+	jobID, _ := strconv.ParseInt(rc.request.FormValue("job"), 10, 64)
+	optionsName := rc.request.FormValue("options")
+	options := formOptions[optionsName]
+	batch := options != nil && options.filter
+	if len(ids) == 0 && jobID == 0 && !batch && !rc.isAdmin {
+		return httperror.ErrForbidden
+	}
+
+	conf, err := svc.store.GetConference(rc.ctx)
+	if err != nil {
+		return err
+	}
+
+	def := "avery5392"
+	var job *model.PrintJob
+	if jobID != 0 {
+		job, err = svc.store.GetPrintJob(rc.ctx, jobID)
+		if err == store.ErrNotFound {
+			return httperror.ErrNotFound
+		} else if err != nil {
+			return err
 		}
-		vcard = append(vcard, name...)
-		vcard = append(vcard, ':')
-		for i := range value {
-			b := value[i]
-			switch b {
-			case '\\':
-				vcard = append(vcard, `\\`...)
-			case '\n':
-				vcard = append(vcard, `\n`...)
-			case '\r':
-				vcard = append(vcard, `\r`...)
-			case ',':
-				vcard = append(vcard, `\,`...)
-			case ':':
-				vcard = append(vcard, `\:`...)
-			case ';':
-				vcard = append(vcard, `\;`...)
-			default:
-				vcard = append(vcard, b)
+		ids = job.ParticipantIDs
+		if job.Template != "" {
+			def = job.Template
+		}
+	}
+
+	t, err := rc.labelTemplate(conf, def)
+	if err != nil {
+		return err
+	}
+
+	var participants []*model.Participant
+	if len(ids) > 0 {
+		participants, err = svc.store.GetParticipantsByID(rc.ctx, ids)
+		if err != nil {
+			return err
+		}
+	} else if batch {
+		var jobs []*model.PrintJob
+		participants, err = svc.store.GetAllParticipants(rc.ctx)
+		if err != nil {
+			return err
+		}
+		jobs, err = svc.store.GetPrintJobs(rc.ctx, -1)
+		if err != nil {
+			return err
+		}
+		// Participants stay in the print queue until their forms are
+		// printed. Skip participants with a badge in a printed badge job.
+		badged := make(map[string]bool)
+		for _, j := range jobs {
+			if j.IsBadges() && j.Status == model.PrintJobPrinted {
+				for _, id := range j.ParticipantIDs {
+					badged[id] = true
+				}
 			}
 		}
-		vcard = append(vcard, "\r\n"...)
+		participants = model.FilterParticipants(participants, func(p *model.Participant) bool { return !badged[p.ID] })
+		participants, _ = selectForms(participants, options)
+		if len(participants) == 0 {
+			return rc.redirect("/dashboard/printJobs", "info", "The print queue is empty.")
+		}
+		job, err = svc.printJob(rc, rc.station(), optionsName, model.PrintJobBadges, t.Name, participantIDs(participants))
+		if err != nil {
+			return err
+		}
+		// The participant list query does not include the badge
+		// fields.
+		participants, err = svc.store.GetParticipantsByID(rc.ctx, job.ParticipantIDs)
+		if err != nil {
+			return err
+		}
+	} else {
+		participants, err = svc.store.GetAllParticipantsFull(rc.ctx)
+		if err != nil {
+			return err
+		}
+		model.SortParticipants(participants, "")
+	}
+
+	badges := make([]*printout.BadgeData, len(participants))
+	for i, p := range participants {
+		badges[i] = printout.NewBadgeData(p, conf.ParticipantLunch(p), svc.conferenceDate.Year())
+	}
+	doc := pdf.New()
+	printout.Badges(doc, t, badges)
+
+	name := "badges"
+	if job != nil {
+		name = fmt.Sprintf("badges-%d", job.ID)
+	}
+	return rc.writePDF(doc, name)
+}
+
+func (svc *dashboardService) Serve_dashboard_vcard(rc *requestContext) error {
+	rc.request.ParseForm()
+	var props []string
+	for name, values := range rc.request.Form {
+		props = append(props, name, values[0])
 	}
-	vcard = append(vcard, "END:VCARD\r\n"...)
-	code, err := qr.Encode(string(vcard), qr.L)
+	code, err := qr.Encode(printout.VCard(props...), qr.L)
 	if err != nil {
 		return err
 	}
@@ -925,29 +2682,22 @@ func (svc *dashboardService) Serve_dashboard_exportClasses(rc *requestContext) e
 		return err
 	}
 
-	rc.response.Header().Set("Content-Type", "text/csv")
-	rc.response.Header().Set("Content-Disposition", `attachment; filename="classes.csv"`)
-
-	w := csv.NewWriter(rc.response)
-	w.Write([]string{
-		"Number",
-		"Length",
-		"Title",
-		"Instructors",
-		"InstructorEmails",
-	})
+	t := newExportTable("classes",
+		col("Number", xlsx.Number),
+		col("Length", xlsx.Number),
+		col("Title", xlsx.String),
+		col("Instructors", xlsx.String),
+		col("InstructorEmails", xlsx.String))
 
 	for _, c := range classes {
-		w.Write([]string{
-			fmt.Sprintf("%d", c.Number),
-			fmt.Sprintf("%d", c.Length),
+		t.add(
+			c.Number,
+			c.Length,
 			c.Title,
 			c.InstructorNames,
-			c.InstructorEmails,
-		})
+			c.InstructorEmails)
 	}
-	w.Flush()
-	return nil
+	return rc.writeExport(t)
 }
 
 func (svc *dashboardService) Serve_dashboard_exportParticipants(rc *requestContext) error {
@@ -965,36 +2715,32 @@ func (svc *dashboardService) Serve_dashboard_exportParticipants(rc *requestConte
 		return err
 	}
 
-	rc.response.Header().Set("Content-Type", "text/csv")
-	rc.response.Header().Set("Content-Disposition", `attachment; filename="participants.csv"`)
-
-	w := csv.NewWriter(rc.response)
-	record := []string{
-		"ID",
-		"Reg#",
-		"Name",
-		"Type",
-		"Email",
-		"Email2",
-		"Phone",
-		"City",
-		"State",
-		"Zip",
-		"StaffRole",
-		"Council",
-		"District",
-		"Unit",
-		"Marketing",
-		"ScoutingYears",
-		"BSA#",
-		"Banquet",
-		"No Show",
-		"Staff Notes",
-	}
+	t := newExportTable("participants",
+		col("ID", xlsx.String),
+		col("Reg#", xlsx.String),
+		col("Name", xlsx.String),
+		col("Type", xlsx.String),
+		col("Email", xlsx.String),
+		col("Email2", xlsx.String),
+		col("Phone", xlsx.String),
+		col("City", xlsx.String),
+		col("State", xlsx.String),
+		col("Zip", xlsx.String),
+		col("StaffRole", xlsx.String),
+		col("Council", xlsx.String),
+		col("District", xlsx.String),
+		col("Unit", xlsx.String),
+		col("Marketing", xlsx.String),
+		col("ScoutingYears", xlsx.String),
+		col("BSA#", xlsx.String),
+		col("Banquet", xlsx.Bool),
+		col("No Show", xlsx.Bool),
+		col("Staff Notes", xlsx.String))
 	for i := 0; i < model.NumSession; i++ {
-		record = append(record, fmt.Sprintf("class_%d", i+1), fmt.Sprintf("instr_%d", i+1))
+		t.columns = append(t.columns,
+			col(fmt.Sprintf("class_%d", i+1), xlsx.String),
+			col(fmt.Sprintf("instr_%d", i+1), xlsx.Bool))
 	}
-	w.Write(record)
 
 	for _, p := range participants {
 		sessionClasses := classInfo.ParticipantSessionClasses(p)
@@ -1004,7 +2750,7 @@ func (svc *dashboardService) Serve_dashboard_exportParticipants(rc *requestConte
 			email2 = p.RegisteredByEmail
 		}
 
-		record := []string{
+		row := []interface{}{
 			p.ID,
 			p.RegistrationNumber,
 			p.Name(),
@@ -1022,17 +2768,24 @@ func (svc *dashboardService) Serve_dashboard_exportParticipants(rc *requestConte
 			p.Marketing,
 			p.ScoutingYears,
 			p.BSANumber,
-			strconv.FormatBool(p.OABanquet),
-			strconv.FormatBool(p.NoShow),
+			p.OABanquet,
+			p.NoShow,
 			strings.Replace(p.Notes, "\n", " ", -1),
 		}
 		for _, c := range sessionClasses {
-			record = append(record, c.NumberDotPart(), strconv.FormatBool(c.Instructor))
+			row = append(row, c.NumberDotPart(), c.Instructor)
 		}
-		w.Write(record)
+		t.add(row...)
 	}
-	w.Flush()
-	return nil
+	return rc.writeExport(t)
+}
+
+// ratingValue returns the export value for an evaluation rating.
+func ratingValue(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
 }
 
 func (svc *dashboardService) Serve_dashboard_exportConferenceEvaluations(rc *requestContext) error {
@@ -1045,48 +2798,41 @@ func (svc *dashboardService) Serve_dashboard_exportConferenceEvaluations(rc *req
 		return err
 	}
 
-	rc.response.Header().Set("Content-Type", "text/csv")
-	rc.response.Header().Set("Content-Disposition", `attachment; filename="conferenceEvaluations.csv"`)
-
-	w := csv.NewWriter(rc.response)
-	w.Write([]string{
-		"ParticipantID",
-		"Experience",
-		"Promotion",
-		"Registration",
-		"Checkin",
-		"Midway",
-		"Lunch",
-		"Facilities",
-		"Website",
-		"SignageWayfinding",
-		"LearnTopics",
-		"TeachTopics",
-		"Comments",
-		"Source",
-		"Updated",
-	})
+	t := newExportTable("conferenceEvaluations",
+		col("ParticipantID", xlsx.String),
+		col("Experience", xlsx.Number),
+		col("Promotion", xlsx.Number),
+		col("Registration", xlsx.Number),
+		col("Checkin", xlsx.Number),
+		col("Midway", xlsx.Number),
+		col("Lunch", xlsx.Number),
+		col("Facilities", xlsx.Number),
+		col("Website", xlsx.Number),
+		col("SignageWayfinding", xlsx.Number),
+		col("LearnTopics", xlsx.String),
+		col("TeachTopics", xlsx.String),
+		col("Comments", xlsx.String),
+		col("Source", xlsx.String),
+		col("Updated", xlsx.DateTime))
 	for _, e := range conferenceEvaluations {
-		w.Write([]string{
+		t.add(
 			e.ParticipantID,
-			ratingString(e.ExperienceRating),
-			ratingString(e.PromotionRating),
-			ratingString(e.RegistrationRating),
-			ratingString(e.CheckinRating),
-			ratingString(e.MidwayRating),
-			ratingString(e.LunchRating),
-			ratingString(e.FacilitiesRating),
-			ratingString(e.WebsiteRating),
-			ratingString(e.SignageWayfindingRating),
+			ratingValue(e.ExperienceRating),
+			ratingValue(e.PromotionRating),
+			ratingValue(e.RegistrationRating),
+			ratingValue(e.CheckinRating),
+			ratingValue(e.MidwayRating),
+			ratingValue(e.LunchRating),
+			ratingValue(e.FacilitiesRating),
+			ratingValue(e.WebsiteRating),
+			ratingValue(e.SignageWayfindingRating),
 			strings.ReplaceAll(e.LearnTopics, "\n", `\n`),
 			strings.ReplaceAll(e.TeachTopics, "\n", `\n`),
 			strings.ReplaceAll(e.Comments, "\n", `\n`),
 			e.Source,
-			e.Updated.In(model.TimeLocation).Format(time.RFC3339),
-		})
+			e.Updated)
 	}
-	w.Flush()
-	return nil
+	return rc.writeExport(t)
 }
 
 func (svc *dashboardService) Serve_dashboard_exportSessionEvaluations(rc *requestContext) error {
@@ -1099,38 +2845,31 @@ func (svc *dashboardService) Serve_dashboard_exportSessionEvaluations(rc *reques
 		return err
 	}
 
-	rc.response.Header().Set("Content-Type", "text/csv")
-	rc.response.Header().Set("Content-Disposition", `attachment; filename="sessionEvaluations.csv"`)
-
-	w := csv.NewWriter(rc.response)
-	w.Write([]string{
-		"ParticipantID",
-		"Session",
-		"Class",
-		"Knowledge",
-		"Presentation",
-		"Usefulness",
-		"Overall",
-		"Comments",
-		"Source",
-		"Updated",
-	})
+	t := newExportTable("sessionEvaluations",
+		col("ParticipantID", xlsx.String),
+		col("Session", xlsx.Number),
+		col("Class", xlsx.Number),
+		col("Knowledge", xlsx.Number),
+		col("Presentation", xlsx.Number),
+		col("Usefulness", xlsx.Number),
+		col("Overall", xlsx.Number),
+		col("Comments", xlsx.String),
+		col("Source", xlsx.String),
+		col("Updated", xlsx.DateTime))
 	for _, e := range sessionEvaluations {
-		w.Write([]string{
+		t.add(
 			e.ParticipantID,
-			strconv.Itoa(e.Session),
-			strconv.Itoa(e.ClassNumber),
-			ratingString(e.KnowledgeRating),
-			ratingString(e.PresentationRating),
-			ratingString(e.UsefulnessRating),
-			ratingString(e.OverallRating),
+			e.Session,
+			e.ClassNumber,
+			ratingValue(e.KnowledgeRating),
+			ratingValue(e.PresentationRating),
+			ratingValue(e.UsefulnessRating),
+			ratingValue(e.OverallRating),
 			strings.ReplaceAll(e.Comments, "\n", `\n`),
 			e.Source,
-			e.Updated.In(model.TimeLocation).Format(time.RFC3339),
-		})
+			e.Updated)
 	}
-	w.Flush()
-	return nil
+	return rc.writeExport(t)
 }
 
 func (svc *dashboardService) rand() (uint32, error) {
@@ -1141,16 +2880,9 @@ func (svc *dashboardService) rand() (uint32, error) {
 	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
 }
 
-func (svc *dashboardService) Serve_dashboard_evalCodes(rc *requestContext) error {
-	if !rc.isStaff {
-		return httperror.ErrForbidden
-	}
-
-	classes, err := svc.store.GetAllClassesFull(rc.ctx)
-	if err != nil {
-		return err
-	}
-
+// assignCodes assigns an access token and evaluation codes to each class
+// that does not already have them.
+func (svc *dashboardService) assignCodes(classes []*model.Class) error {
 	// Collect codes in use.
 	evaluationCodes := make(map[string]int)
 	accessTokens := make(map[string]int)
@@ -1222,11 +2954,70 @@ func (svc *dashboardService) Serve_dashboard_evalCodes(rc *requestContext) error
 		class.EvaluationCodes = strings.Join(codes, ", ")
 	}
 
-	rc.response.Header().Set("Content-Type", "text/csv")
-	rc.response.Header().Set("Content-Disposition", `attachment; filename="classes.csv"`)
+	return nil
+}
+
+// writeCodes writes class access tokens and evaluation codes to the planning
+// spreadsheet.
+func (svc *dashboardService) writeCodes(rc *requestContext, classes []*model.Class) error {
+	src := sheet.NewSource(svc.config)
+	cw, ok := src.(sheet.CodeWriter)
+	if !ok {
+		return &httperror.Error{Status: http.StatusBadRequest, Message: "Planning spreadsheet source does not support writes"}
+	}
+	n, err := cw.WriteCodes(rc.ctx, classes)
+	if err != nil {
+		return &httperror.Error{Status: http.StatusInternalServerError, Message: fmt.Sprintf("Error writing codes to planning spreadsheet: %v", err), Err: err}
+	}
+
+	// Reload so that the stored classes match the spreadsheet. The reload
+	// is validated the same as an import because the spreadsheet may have
+	// changed since the last import.
+	rows, err := src.ClassRows(rc.ctx)
+	if err != nil {
+		return err
+	}
+	return svc.importClasses(rc, rows, nil, "sheet", fmt.Sprintf(", %d cells updated in planning spreadsheet", n))
+}
+
+// Serve_dashboard_evalCodes assigns missing access tokens and evaluation
+// codes to classes. A GET request downloads the codes. A POST request writes
+// the codes to the planning spreadsheet and reloads the classes from the
+// spreadsheet.
+func (svc *dashboardService) Serve_dashboard_evalCodes(rc *requestContext) error {
+	if !rc.isStaff {
+		return httperror.ErrForbidden
+	}
+
+	classes, err := svc.store.GetAllClassesFull(rc.ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.assignCodes(classes); err != nil {
+		return err
+	}
+
+	if rc.request.Method == "POST" {
+		return svc.writeCodes(rc, classes)
+	}
 
 	model.SortClasses(classes, "")
 
+	if rc.request.FormValue("format") == "xlsx" {
+		t := newExportTable("classes",
+			col("class", xlsx.Number),
+			col("accessToken", xlsx.String),
+			col("evaluationCodes", xlsx.String))
+		for _, class := range classes {
+			t.add(class.Number, class.AccessToken, class.EvaluationCodes)
+		}
+		return rc.writeExport(t)
+	}
+
+	rc.response.Header().Set("Content-Type", "text/csv")
+	rc.response.Header().Set("Content-Disposition", `attachment; filename="classes.csv"`)
+
 	// Quote tokens and codes in output to prevent spreadsheet from
 	// interpreting the values as numbers.
 	fmt.Fprintf(rc.response, "\"class\",\"accessToken\",\"evaluationCodes\"\n")
@@ -1456,6 +3247,10 @@ func (svc *dashboardService) Serve_dashboard_evaluations_(rc *requestContext) er
 		return err
 	}
 
+	if updatedConference != nil || len(updateSessions) > 0 {
+		svc.notifyEvaluation(rc.ctx, data.Participant.ID, updateSessions, updatedConference)
+	}
+
 	if len(description) == 0 {
 		description = []string{"no changes"}
 	}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/xlsx"
+)
+
+// exportTable is a table of values for download as CSV or XLSX.
+type exportTable struct {
+	// Download file name without the extension.
+	name    string
+	columns []xlsx.Column
+	rows    [][]interface{}
+}
+
+func newExportTable(name string, columns ...xlsx.Column) *exportTable {
+	return &exportTable{name: name, columns: columns}
+}
+
+// col is shorthand for creating an xlsx.Column.
+func col(name string, typ xlsx.CellType) xlsx.Column {
+	return xlsx.Column{Name: name, Type: typ}
+}
+
+// exportDate is a date without a time of day. In CSV files, the date is
+// formatted as YYYY-MM-DD. In XLSX files, the date is written as a date
+// cell.
+type exportDate time.Time
+
+// add adds a row to the table. See xlsx.Writer.Write for supported value
+// types.
+func (t *exportTable) add(values ...interface{}) {
+	t.rows = append(t.rows, values)
+}
+
+// csvValue formats v for a CSV file.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.In(model.TimeLocation).Format(time.RFC3339)
+	case exportDate:
+		t := time.Time(v)
+		if t.IsZero() {
+			return ""
+		}
+		return t.In(model.TimeLocation).Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeExport writes the table to the response. The table is written in XLSX
+// format if the format request parameter is "xlsx" and in CSV format
+// otherwise.
+func (rc *requestContext) writeExport(t *exportTable) error {
+	if rc.request.FormValue("format") == "xlsx" {
+		w := xlsx.NewWriter(t.name, t.columns)
+		for _, row := range t.rows {
+			for i, v := range row {
+				switch tv := v.(type) {
+				case time.Time:
+					// Show times in the conference time zone.
+					row[i] = tv.In(model.TimeLocation)
+				case exportDate:
+					row[i] = time.Time(tv).In(model.TimeLocation)
+				}
+			}
+			if err := w.Write(row...); err != nil {
+				return err
+			}
+		}
+		rc.response.Header().Set("Content-Type", xlsx.ContentType)
+		rc.response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, t.name))
+		_, err := w.WriteTo(rc.response)
+		return err
+	}
+
+	rc.response.Header().Set("Content-Type", "text/csv")
+	rc.response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, t.name))
+
+	w := csv.NewWriter(rc.response)
+	record := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		record[i] = c.Name
+	}
+	w.Write(record)
+	for _, row := range t.rows {
+		record = record[:0]
+		for _, v := range row {
+			record = append(record, csvValue(v))
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return w.Error()
+}
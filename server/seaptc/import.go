@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/seaptc/server/dk"
+	"github.com/seaptc/server/importer"
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/xlsx"
+)
+
+// parseRegistrations parses an uploaded registration export in CSV or XLSX
+// format. The format is determined by the file name extension. The
+// Doubleknot parser is used when profileName is blank. Otherwise, the export
+// is parsed using the named import profile from the conference.
+func (a *application) parseRegistrations(ctx context.Context, rd io.Reader, fileName string, profileName string) ([]*model.Participant, error) {
+	conf, err := a.store.GetCachedConference(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var profile *model.ImportProfile
+	if profileName != "" {
+		profile = conf.ImportProfile(profileName)
+		if profile == nil {
+			return nil, fmt.Errorf("import profile %q not found", profileName)
+		}
+	}
+
+	if !xlsx.IsXLSX(fileName) {
+		if profile == nil {
+			return dk.ParseCSV(rd, conf)
+		}
+		return importer.ParseCSV(profile, rd)
+	}
+
+	rows, err := xlsx.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return dk.ParseRows(rows, conf)
+	}
+	return importer.ParseRows(profile, rows)
+}
@@ -16,11 +16,14 @@ var (
 	jsonLogging   = os.Getenv("GAE_SERVICE") != ""
 )
 
+// traceIDKey is the context key for the App Engine trace ID.
+type traceIDKey struct{}
+
 func newContextWithTraceID(ctx context.Context, r *http.Request) context.Context {
 	// Use App Engine traceid for correlation in the log viewer.
 	s := r.Header.Get("X-Cloud-Trace-Context")
 	if i := strings.IndexByte(s, '/'); i > 0 {
-		return context.WithValue(ctx, "traceID", traceIDPrefix+s[:i])
+		return context.WithValue(ctx, traceIDKey{}, traceIDPrefix+s[:i])
 	}
 	return ctx
 }
@@ -40,7 +43,7 @@ func logf(ctx context.Context, severity string, format string, args ...interface
 			"message":  fmt.Sprintf(format, args...),
 		}
 
-		if traceID, ok := ctx.Value("traceid").(string); ok {
+		if traceID, ok := ctx.Value(traceIDKey{}).(string); ok {
 			m["logging.googleapis.com/trace"] = traceID
 		}
 		p, _ := json.Marshal(m)
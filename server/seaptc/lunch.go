@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/seaptc/server/model"
+)
+
+// decodeJSON decodes the JSON text s to v. Unknown fields are reported as
+// errors to catch misspelled field names.
+func decodeJSON(s string, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader([]byte(s)))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+// lunchPreviewRow compares the number of participants assigned to a lunch
+// by the current and proposed lunch configurations.
+type lunchPreviewRow struct {
+	Name     string
+	Location string
+	Seating  int
+	Current  int
+	Proposed int
+}
+
+func (r *lunchPreviewRow) Change() int {
+	return r.Proposed - r.Current
+}
+
+type lunchRuleCount struct {
+	Rule  *model.LunchRule
+	Count int
+}
+
+// lunchPreview is the effect of a proposed lunch configuration.
+type lunchPreview struct {
+	Rows     []*lunchPreviewRow
+	Rules    []*lunchRuleCount
+	Seatings []*model.LunchSeating
+
+	// Number of participants assigned to a different lunch.
+	Changed int
+	Total   int
+}
+
+func newLunchPreview(participants []*model.Participant, current, proposed *model.Conference) *lunchPreview {
+	lp := &lunchPreview{Total: len(participants)}
+	rows := make(map[string]*lunchPreviewRow)
+	row := func(l *model.Lunch) *lunchPreviewRow {
+		r := rows[l.Name]
+		if r == nil {
+			r = &lunchPreviewRow{Name: l.Name, Location: l.Location, Seating: l.Seating}
+			rows[l.Name] = r
+			lp.Rows = append(lp.Rows, r)
+		}
+		return r
+	}
+	// Show proposed lunches in the order listed.
+	for _, l := range proposed.Lunches {
+		row(l)
+	}
+
+	ruleCounts := make(map[*model.LunchRule]*lunchRuleCount)
+	for _, r := range proposed.LunchRules {
+		rc := &lunchRuleCount{Rule: r}
+		ruleCounts[r] = rc
+		lp.Rules = append(lp.Rules, rc)
+	}
+
+	for _, p := range participants {
+		cl := current.ParticipantLunch(p)
+		pl := proposed.ParticipantLunch(p)
+		row(cl).Current++
+		row(pl).Proposed++
+		if cl.Name != pl.Name {
+			lp.Changed++
+		}
+		if r := proposed.ParticipantLunchRule(p); r != nil {
+			ruleCounts[r].Count++
+		}
+	}
+
+	lp.Seatings = proposed.LunchSeatings(participants)
+
+	// Lunches removed by the proposed configuration sort last.
+	sort.SliceStable(lp.Rows, func(i, j int) bool {
+		return proposed.Lunch(lp.Rows[i].Name) != nil && proposed.Lunch(lp.Rows[j].Name) == nil
+	})
+	return lp
+}
+
+// lunchDietary is the dietary needs of the participants at a lunch.
+type lunchDietary struct {
+	Lunch *model.Lunch
+
+	// Number of participants at the lunch.
+	Total int
+
+	// Number of participants by dietary tag name and AllergyNotesTag.
+	Tags map[string]int
+
+	// Participants with dietary needs, most severe first.
+	Participants []*model.Participant
+}
+
+// None returns the number of participants without dietary needs.
+func (ld *lunchDietary) None() int {
+	return ld.Total - len(ld.Participants)
+}
+
+// newLunchDietary groups the participants by lunch. The lunches are returned
+// in conference order. The dietary tags for all participants are also
+// returned.
+func newLunchDietary(conf *model.Conference, participants []*model.Participant) ([]*lunchDietary, []*model.DietaryTagCount) {
+	var result []*lunchDietary
+	byLunch := make(map[*model.Lunch]*lunchDietary)
+	add := func(l *model.Lunch) *lunchDietary {
+		ld := byLunch[l]
+		if ld == nil {
+			ld = &lunchDietary{Lunch: l, Tags: make(map[string]int)}
+			byLunch[l] = ld
+			result = append(result, ld)
+		}
+		return ld
+	}
+	for _, l := range conf.Lunches {
+		add(l)
+	}
+
+	participants = append([]*model.Participant(nil), participants...)
+	model.SortParticipants(participants, "")
+	conf.SortDietary(participants)
+	for _, p := range participants {
+		ld := add(conf.ParticipantLunch(p))
+		ld.Total++
+		if !p.HasDietaryNeeds() {
+			continue
+		}
+		ld.Participants = append(ld.Participants, p)
+		for _, name := range p.DietaryTags() {
+			ld.Tags[conf.DietaryTag(name).Name]++
+		}
+		if p.Allergies != "" {
+			ld.Tags[model.AllergyNotesTag]++
+		}
+	}
+	return result, conf.CountDietaryTags(participants)
+}
+
+// dietaryClass returns the Bootstrap contextual class for highlighting a
+// dietary severity.
+func dietaryClass(severity string) string {
+	switch severity {
+	case model.DietaryAllergy:
+		return "table-danger"
+	case model.DietaryIntolerance:
+		return "table-warning"
+	default:
+		return ""
+	}
+}
+
+// catererOrder is the meal order for a lunch location and seating.
+type catererOrder struct {
+	Seating *model.LunchSeating
+
+	// Percentage added to the regular meals.
+	Buffer int
+
+	// Staff and instructor meals. Staff and instructors are also counted
+	// in the other meals.
+	Staff       int
+	Instructors int
+
+	// Special meals grouped by dietary tags, most severe first.
+	Meals []*catererMeal
+
+	// Participants with dietary needs, most severe first.
+	Special []*model.Participant
+}
+
+// catererMeal is the number of special meals with the same dietary tags.
+type catererMeal struct {
+	Name     string
+	Severity string
+	Count    int
+}
+
+func (o *catererOrder) addMeal(p *model.Participant, severity string) {
+	name := p.DietaryRestrictions
+	if p.Allergies != "" {
+		name = strings.TrimPrefix(name+"; Allergy", "; ")
+	}
+	for _, m := range o.Meals {
+		if m.Name == name {
+			m.Count++
+			return
+		}
+	}
+	o.Meals = append(o.Meals, &catererMeal{Name: name, Severity: severity, Count: 1})
+}
+
+// Regular returns the number of participants without dietary needs.
+func (o *catererOrder) Regular() int {
+	return o.Seating.Count - len(o.Special)
+}
+
+// BufferMeals returns the number of regular meals added for the buffer.
+func (o *catererOrder) BufferMeals() int {
+	return (o.Regular()*o.Buffer + 99) / 100
+}
+
+// Total returns the number of meals to order.
+func (o *catererOrder) Total() int {
+	return o.Seating.Count + o.BufferMeals()
+}
+
+// Caterers returns the caterer contacts for the lunches at the seating.
+func (o *catererOrder) Caterers() []*model.Lunch {
+	var result []*model.Lunch
+	for _, l := range o.Seating.Lunches {
+		if l.CatererEmail != "" {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// Text returns the order as plain text for an email message.
+func (o *catererOrder) Text() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s, seating %d\n\n", o.Seating.Location, o.Seating.Seating)
+	fmt.Fprintf(&buf, "Regular meals: %d + %d buffer = %d\n", o.Regular(), o.BufferMeals(), o.Regular()+o.BufferMeals())
+	for _, m := range o.Meals {
+		fmt.Fprintf(&buf, "%s meals: %d\n", m.Name, m.Count)
+	}
+	fmt.Fprintf(&buf, "Total meals: %d\n", o.Total())
+	fmt.Fprintf(&buf, "Included in the total: %d staff meals, %d instructor meals\n", o.Staff, o.Instructors)
+	if len(o.Special) > 0 {
+		fmt.Fprintf(&buf, "\nSpecial meals:\n")
+		for _, p := range o.Special {
+			fmt.Fprintf(&buf, "%s: %s\n", p.Name(), p.DietarySummary())
+		}
+	}
+	return buf.String()
+}
+
+// Mailto returns a mailto URL for sending the order to the caterers.
+func (o *catererOrder) Mailto(subject string) string {
+	var to []string
+	for _, l := range o.Caterers() {
+		to = append(to, l.CatererEmail)
+	}
+	return "mailto:" + strings.Join(to, ",") + "?subject=" + mailtoEscape(subject) + "&body=" + mailtoEscape(o.Text())
+}
+
+// mailtoEscape escapes s for a mailto URL header value. Mail clients do not
+// decode + as a space.
+func mailtoEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// newCatererOrders returns the orders for each lunch location and seating in
+// lunch order. Participants at lunches that are not in the conference lunch
+// list are not included.
+func newCatererOrders(conf *model.Conference, participants []*model.Participant, buffer int) []*catererOrder {
+	var orders []*catererOrder
+	byLunch := make(map[*model.Lunch]*catererOrder)
+	for _, s := range conf.LunchSeatings(participants) {
+		o := &catererOrder{Seating: s, Buffer: buffer}
+		orders = append(orders, o)
+		for _, l := range s.Lunches {
+			byLunch[l] = o
+		}
+	}
+
+	participants = append([]*model.Participant(nil), participants...)
+	model.SortParticipants(participants, "")
+	conf.SortDietary(participants)
+	for _, p := range participants {
+		o := byLunch[conf.ParticipantLunch(p)]
+		if o == nil {
+			continue
+		}
+		if p.Staff {
+			o.Staff++
+		}
+		if p.StaffRole == "Instructor" || len(p.InstructorClasses) > 0 {
+			o.Instructors++
+		}
+		if p.HasDietaryNeeds() {
+			o.Special = append(o.Special, p)
+			o.addMeal(p, conf.DietarySeverity(p))
+		}
+	}
+	return orders
+}
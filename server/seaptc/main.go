@@ -23,6 +23,7 @@ func main() {
 
 	addr := flag.String("addr", defaultAddr, "")
 	dir := flag.String("dir", "assets", "")
+	importInterval := flag.Duration("importInterval", 0, "Import registration export at this interval, use App Engine cron if zero")
 	store.SetupFlags()
 	flag.Parse()
 
@@ -36,7 +37,8 @@ func main() {
 		&dashboardService{},
 		&loginService{},
 		&participantService{},
-		&apiService{})
+		&apiService{},
+		&cronService{importInterval: *importInterval})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/seaptc/server/model"
 	"github.com/seaptc/server/store"
+	"github.com/seaptc/server/xlsx"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/garyburd/web/httperror"
@@ -20,12 +21,15 @@ import (
 type participantService struct {
 	*application
 	templates struct {
-		Error  *templates.Template `html:"participant/error.html participant/root.html common.html"`
-		Closed *templates.Template `html:"participant/closed.html participant/root.html common.html"`
-		Home   *templates.Template `html:"participant/home.html blurbs.html participant/root.html common.html"`
-		Login  *templates.Template `html:"participant/login.html participant/root.html common.html"`
-		Eval1  *templates.Template `html:"participant/eval1.html participant/root.html common.html"`
-		Eval2  *templates.Template `html:"participant/eval2.html participant/root.html common.html"`
+		Error   *templates.Template `html:"participant/error.html participant/root.html common.html"`
+		Closed  *templates.Template `html:"participant/closed.html participant/root.html common.html"`
+		Home    *templates.Template `html:"participant/home.html blurbs.html participant/root.html common.html"`
+		Login   *templates.Template `html:"participant/login.html participant/root.html common.html"`
+		Planner *templates.Template `html:"participant/planner.html participant/root.html common.html"`
+		Eval1   *templates.Template `html:"participant/eval1.html participant/root.html common.html"`
+		Eval2   *templates.Template `html:"participant/eval2.html participant/root.html common.html"`
+		Profile *templates.Template `html:"participant/profile.html participant/root.html common.html"`
+		Change  *templates.Template `html:"participant/change.html participant/root.html common.html"`
 	}
 }
 
@@ -98,6 +102,11 @@ func (svc *participantService) serviceState(rc *requestContext) (int, *model.Con
 	return state, conf, nil
 }
 
+// participantOpen returns true if logged in participants can use the site.
+func participantOpen(state int, conf *model.Conference) bool {
+	return state == stateOpen || state == stateGrace || (state == stateBefore && conf.ClassChanges)
+}
+
 func (svc *participantService) Serve_(rc *requestContext) error {
 	if rc.request.URL.Path != "/" {
 		rc.participantName = ""
@@ -108,7 +117,7 @@ func (svc *participantService) Serve_(rc *requestContext) error {
 	if err != nil {
 		return err
 	}
-	if state == stateBefore || state == stateAfter ||
+	if !participantOpen(state, conf) ||
 		(state == stateGrace && rc.participantID == "") {
 		rc.participantName = ""
 		return svc.serveHomeClosed(rc, conf, state)
@@ -119,10 +128,10 @@ func (svc *participantService) Serve_(rc *requestContext) error {
 		return svc.serveHomeLogin(rc, conf)
 	}
 
-	return svc.serveHome(rc, conf)
+	return svc.serveHome(rc, conf, state)
 }
 
-func (svc *participantService) serveHome(rc *requestContext, conf *model.Conference) error {
+func (svc *participantService) serveHome(rc *requestContext, conf *model.Conference, state int) error {
 	var data struct {
 		Participant         *model.Participant
 		Conference          *model.Conference
@@ -130,8 +139,12 @@ func (svc *participantService) serveHome(rc *requestContext, conf *model.Confere
 		Lunch               *model.Lunch
 		EvaluatedClasses    []*model.SessionClass
 		EvaluatedConference bool
+		Before              bool
+		ClassChanges        []*model.ClassChange
 	}
 
+	data.Before = state == stateBefore
+
 	classInfo, err := svc.store.GetCachedClassInfo(rc.ctx)
 	if err != nil {
 		return err
@@ -175,6 +188,14 @@ func (svc *participantService) serveHome(rc *requestContext, conf *model.Confere
 		return nil
 	})
 
+	if conf.ClassChanges {
+		g.Go(func() error {
+			var err error
+			data.ClassChanges, err = svc.store.GetParticipantClassChanges(rc.ctx, rc.participantID)
+			return err
+		})
+	}
+
 	if err := g.Wait(); err != nil {
 		return err
 	}
@@ -403,9 +424,421 @@ func (svc *participantService) Serve_eval(rc *requestContext) error {
 		return err
 	}
 
+	var sessionEvaluations []*model.SessionEvaluation
+	if sessionEvaluation != nil {
+		sessionEvaluations = append(sessionEvaluations, sessionEvaluation)
+	}
+	svc.notifyEvaluation(rc.ctx, rc.participantID, sessionEvaluations, conferenceEvaluation)
+
 	return rc.redirect("/", "info", "Evaluation recorded for %s.", strings.Join(description, " and "))
 }
 
+func (svc *participantService) Serve_profile(rc *requestContext) error {
+	state, conf, err := svc.serviceState(rc)
+	if err != nil {
+		return err
+	}
+	if !participantOpen(state, conf) || rc.participantID == "" {
+		http.Redirect(rc.response, rc.request, "/", http.StatusSeeOther)
+		return nil
+	}
+
+	participant, err := svc.store.GetParticipant(rc.ctx, rc.participantID)
+	if err != nil {
+		return err
+	}
+
+	rc.request.ParseForm()
+	data := struct {
+		Form        url.Values
+		Invalid     map[string]string
+		Participant *model.Participant
+		DietaryTags []*model.DietaryTag
+	}{
+		Form:        rc.request.Form,
+		Invalid:     make(map[string]string),
+		Participant: participant,
+	}
+
+	// Offer the configured tags and any other tags set on the participant
+	// at registration.
+	data.DietaryTags = append(data.DietaryTags, conf.DietaryTagList()...)
+	for _, name := range participant.DietaryTags() {
+		if !containsTag(data.DietaryTags, name) {
+			data.DietaryTags = append(data.DietaryTags, conf.DietaryTag(name))
+		}
+	}
+
+	if rc.request.Method != "POST" {
+		data.Form.Set("nickname", participant.Nickname)
+		data.Form.Set("allergies", participant.Allergies)
+		data.Form.Set("phone", participant.Phone)
+		data.Form.Set("showQRCode", blankOrYes(participant.ShowQRCode))
+		data.Form["dietary"] = participant.DietaryTags()
+		return rc.respond(svc.templates.Profile, http.StatusOK, &data)
+	}
+
+	var p model.Participant
+	p.Nickname = strings.TrimSpace(data.Form.Get("nickname"))
+	p.Allergies = strings.TrimSpace(data.Form.Get("allergies"))
+	p.Phone = strings.TrimSpace(data.Form.Get("phone"))
+	p.ShowQRCode = data.Form.Get("showQRCode") != ""
+	var tags []string
+	for _, t := range data.DietaryTags {
+		if containsFold(data.Form["dietary"], t.Name) {
+			tags = append(tags, t.Name)
+		}
+	}
+	p.SetDietaryTags(tags)
+
+	if len([]rune(p.Nickname)) > 40 {
+		data.Invalid["nickname"] = "Nickname is too long."
+	}
+	if len(p.Allergies) > 500 {
+		data.Invalid["allergies"] = "Allergies is too long."
+	}
+	if len(data.Invalid) > 0 {
+		return rc.respond(svc.templates.Profile, http.StatusOK, &data)
+	}
+
+	now := time.Now()
+	err = svc.store.UpdateParticipant(rc.ctx, rc.participantID, func(xp *model.Participant) error {
+		x := *xp
+		xp.SetOverride("nickname", p.Nickname, now)
+		xp.SetOverride("dietaryRestrictions", p.DietaryRestrictions, now)
+		xp.SetOverride("allergies", p.Allergies, now)
+		xp.SetOverride("showQRCode", strconv.FormatBool(p.ShowQRCode), now)
+		xp.SetOverride("phone", p.Phone, now)
+		xp.PrintForm = xp.PrintForm || !xp.EqualPrintFields(&x)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return rc.redirect("/", "info", "Profile updated.")
+}
+
+// Serve_classChange requests a class change or cancels a pending request.
+func (svc *participantService) Serve_classChange(rc *requestContext) error {
+	state, conf, err := svc.serviceState(rc)
+	if err != nil {
+		return err
+	}
+	if state != stateBefore || !conf.ClassChanges || rc.participantID == "" {
+		http.Redirect(rc.response, rc.request, "/", http.StatusSeeOther)
+		return nil
+	}
+
+	if rc.request.Method == "POST" && rc.request.FormValue("action") == "cancel" {
+		id, _ := strconv.ParseInt(rc.request.FormValue("id"), 10, 64)
+		err := svc.store.UpdateClassChange(rc.ctx, id, func(cc *model.ClassChange) error {
+			if cc.ParticipantID != rc.participantID || !cc.IsPending() {
+				return store.ErrNotFound
+			}
+			cc.Status = model.ClassChangeCanceled
+			cc.Updated = time.Now()
+			return nil
+		})
+		switch {
+		case err == store.ErrNotFound:
+			return rc.redirect("/", "info", "The request was already reviewed.")
+		case err != nil:
+			return err
+		}
+		return rc.redirect("/", "info", "Class change request canceled.")
+	}
+
+	var (
+		g           errgroup.Group
+		participant *model.Participant
+		classInfo   *model.ClassInfo
+		registered  map[int]int
+		changes     []*model.ClassChange
+	)
+
+	g.Go(func() error {
+		var err error
+		participant, err = svc.store.GetParticipant(rc.ctx, rc.participantID)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		registered, err = svc.store.GetClassParticipantCounts(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		changes, err = svc.store.GetParticipantClassChanges(rc.ctx, rc.participantID)
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	rc.request.ParseForm()
+	data := struct {
+		Form           url.Values
+		Invalid        map[string]string
+		SessionClasses []*model.SessionClass
+		Drop           []*model.Class
+		Add            []*model.Class
+		Full           map[int]bool
+	}{
+		Form:           rc.request.Form,
+		Invalid:        make(map[string]string),
+		SessionClasses: classInfo.ParticipantSessionClasses(participant),
+		Full:           make(map[int]bool),
+	}
+
+	for _, n := range participant.Classes {
+		if c := classInfo.LookupNumber(n); c != nil {
+			data.Drop = append(data.Drop, c)
+		}
+	}
+	for _, c := range classInfo.Classes() {
+		if !model.IsValidClassNumber(c.Number) || containsInt(participant.Classes, c.Number) {
+			continue
+		}
+		data.Add = append(data.Add, c)
+		data.Full[c.Number] = c.Capacity < 0 || (c.Capacity > 0 && registered[c.Number] >= c.Capacity)
+	}
+
+	if rc.request.Method != "POST" {
+		return rc.respond(svc.templates.Change, http.StatusOK, &data)
+	}
+
+	cc := &model.ClassChange{
+		ParticipantID:   participant.ID,
+		ParticipantName: participant.Name(),
+		Comment:         strings.TrimSpace(data.Form.Get("comment")),
+		Status:          model.ClassChangePending,
+	}
+	cc.Drop, _ = strconv.Atoi(data.Form.Get("drop"))
+	cc.Add, _ = strconv.Atoi(data.Form.Get("add"))
+
+	if cc.Add == 0 {
+		data.Invalid["add"] = "Select a class."
+	} else if problems := classInfo.CheckClassChange(participant, cc, registered); len(problems) > 0 {
+		data.Invalid["add"] = strings.Join(problems, " ")
+	}
+	for _, x := range changes {
+		if x.IsPending() && x.Drop == cc.Drop && x.Add == cc.Add {
+			data.Invalid["add"] = "This change was already requested."
+		}
+	}
+	if len(data.Invalid) > 0 {
+		return rc.respond(svc.templates.Change, http.StatusOK, &data)
+	}
+
+	cc.Created = time.Now()
+	cc.Updated = cc.Created
+	if err := svc.store.AddClassChange(rc.ctx, cc); err != nil {
+		return err
+	}
+	return rc.redirect("/", "info", "Class change requested. Staff will review the request.")
+}
+
+// plannerSlot is a class in a suggested schedule.
+type plannerSlot struct {
+	Number   int
+	Class    *model.Class
+	Elective bool
+
+	// Classes that can replace an elective.
+	Alternatives []*model.Class
+}
+
+// Serve_planner builds a schedule from a suggested schedule. Participants
+// can swap electives for other classes in the same sessions. The schedule
+// is stored in the request parameters so that the planner is available
+// without logging in.
+func (svc *participantService) Serve_planner(rc *requestContext) error {
+	state, _, err := svc.serviceState(rc)
+	if err != nil {
+		return err
+	}
+	if state == stateAfter {
+		http.Redirect(rc.response, rc.request, "/", http.StatusSeeOther)
+		return nil
+	}
+
+	var (
+		g                  errgroup.Group
+		classInfo          *model.ClassInfo
+		suggestedSchedules []*model.SuggestedSchedule
+		participant        *model.Participant
+		registered         map[int]int
+	)
+
+	g.Go(func() error {
+		var err error
+		classInfo, err = svc.store.GetCachedClassInfo(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		suggestedSchedules, err = svc.store.GetSuggestedSchedules(rc.ctx)
+		return err
+	})
+
+	g.Go(func() error {
+		var err error
+		registered, err = svc.store.GetClassParticipantCounts(rc.ctx)
+		return err
+	})
+
+	if rc.participantID != "" {
+		g.Go(func() error {
+			var err error
+			participant, err = svc.store.GetParticipant(rc.ctx, rc.participantID)
+			if err == store.ErrNotFound {
+				participant, err = nil, nil
+			}
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	rc.request.ParseForm()
+	data := struct {
+		Form      url.Values
+		Programs  []*model.ProgramDescription
+		Program   *model.ProgramDescription
+		Schedules []*model.SuggestedSchedule
+		Schedule  int
+		Slots     []*plannerSlot
+		Warnings  []string
+		ClassList string
+	}{
+		Form:     rc.request.Form,
+		Programs: model.ProgramDescriptions[:model.NumPrograms],
+		Schedule: -1,
+	}
+
+	program := -1
+	for i, pd := range data.Programs {
+		if pd.Code == data.Form.Get("program") {
+			program = i
+		}
+	}
+	if program < 0 && participant != nil {
+		if pd := participant.Program(); pd != nil {
+			for i := range data.Programs {
+				if data.Programs[i] == pd {
+					program = i
+				}
+			}
+		}
+	}
+	if program < 0 {
+		return rc.respond(svc.templates.Planner, http.StatusOK, &data)
+	}
+	data.Program = data.Programs[program]
+	data.Form.Set("program", data.Program.Code)
+
+	for _, ss := range suggestedSchedules {
+		if ss.Program == program {
+			data.Schedules = append(data.Schedules, ss)
+		}
+	}
+
+	i, err := strconv.Atoi(data.Form.Get("schedule"))
+	if err != nil || i < 0 || i >= len(data.Schedules) {
+		return rc.respond(svc.templates.Planner, http.StatusOK, &data)
+	}
+	data.Schedule = i
+
+	// Start from the suggested schedule and apply the elective choices in
+	// the class parameters.
+	choices := data.Form["class"]
+	var numbers []int
+	for i, ssc := range data.Schedules[data.Schedule].Classes {
+		slot := &plannerSlot{Number: ssc.Number, Class: classInfo.LookupNumber(ssc.Number), Elective: ssc.Elective}
+		if slot.Class != nil && slot.Elective {
+			slot.Alternatives = classInfo.ScheduleAlternatives(slot.Class, program)
+			if i < len(choices) {
+				n, _ := strconv.Atoi(choices[i])
+				for _, c := range slot.Alternatives {
+					if c.Number == n {
+						slot.Number = n
+						slot.Class = c
+					}
+				}
+			}
+		}
+		if slot.Class == nil {
+			data.Warnings = append(data.Warnings, fmt.Sprintf("Class %d is not offered.", slot.Number))
+		} else if c := slot.Class; c.Capacity < 0 || (c.Capacity > 0 && registered[c.Number] >= c.Capacity) {
+			data.Warnings = append(data.Warnings, fmt.Sprintf("Class %d is full.", slot.Number))
+		}
+		data.Slots = append(data.Slots, slot)
+		numbers = append(numbers, slot.Number)
+	}
+	data.Warnings = append(data.Warnings, classInfo.ScheduleOverlaps(numbers)...)
+
+	if rc.request.FormValue("format") != "" {
+		t := newExportTable("schedule",
+			col("class", xlsx.Number),
+			col("title", xlsx.String),
+			col("startSession", xlsx.Number),
+			col("endSession", xlsx.Number))
+		for _, slot := range data.Slots {
+			if c := slot.Class; c != nil {
+				start, end := c.StartEnd()
+				t.add(c.Number, c.Title, start+1, end+1)
+			}
+		}
+		return rc.writeExport(t)
+	}
+
+	list := make([]string, len(numbers))
+	for i, n := range numbers {
+		list[i] = strconv.Itoa(n)
+	}
+	data.ClassList = strings.Join(list, ", ")
+	return rc.respond(svc.templates.Planner, http.StatusOK, &data)
+}
+
+func containsInt(values []int, n int) bool {
+	for _, v := range values {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTag(tags []*model.DietaryTag, name string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func ratingString(n int) string {
 	if n == 0 {
 		return ""
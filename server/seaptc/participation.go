@@ -0,0 +1,162 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/seaptc/server/model"
+)
+
+// homeCouncil is the council that hosts the conference.
+const homeCouncil = "Chief Seattle"
+
+// unitCounts returns the number of participants from each unit in
+// decreasing order by count.
+func unitCounts(participants []*model.Participant) []model.UnitCount {
+	counts := make(map[model.UnitCount]int)
+	for _, p := range participants {
+		unit := p.Unit()
+		if unit == "" {
+			continue
+		}
+		counts[model.UnitCount{Council: p.Council, District: p.District, Unit: unit}]++
+	}
+	var result []model.UnitCount
+	for uc, n := range counts {
+		uc.Count = n
+		result = append(result, uc)
+	}
+	sortUnitCounts(result)
+	return result
+}
+
+func sortUnitCounts(units []model.UnitCount) {
+	sort.Slice(units, func(i, j int) bool {
+		a, b := units[i], units[j]
+		switch {
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		case a.Council != b.Council:
+			return a.Council < b.Council
+		case a.District != b.District:
+			return a.District < b.District
+		default:
+			return a.Unit < b.Unit
+		}
+	})
+}
+
+type zipCount struct {
+	Zip   string
+	City  string
+	State string
+	Count int
+}
+
+// zipCounts returns the number of participants in each five digit zip code
+// in decreasing order by count. The city and state are the most common
+// values for the zip code.
+func zipCounts(participants []*model.Participant) []*zipCount {
+	zips := make(map[string]*zipCount)
+	places := make(map[string]map[[2]string]int)
+	for _, p := range participants {
+		zip := strings.TrimSpace(p.Zip)
+		if len(zip) > 5 {
+			zip = zip[:5]
+		}
+		zc := zips[zip]
+		if zc == nil {
+			zc = &zipCount{Zip: zip}
+			zips[zip] = zc
+			places[zip] = make(map[[2]string]int)
+		}
+		zc.Count++
+		place := [2]string{strings.TrimSpace(p.City), strings.TrimSpace(p.State)}
+		places[zip][place]++
+		if n := places[zip][place]; n > places[zip][[2]string{zc.City, zc.State}] || (zc.City == "" && zc.State == "") {
+			zc.City, zc.State = place[0], place[1]
+		}
+	}
+	var result []*zipCount
+	for _, zc := range zips {
+		result = append(result, zc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Zip < result[j].Zip
+	})
+	return result
+}
+
+type districtCount struct {
+	Council  string
+	District string
+	Units    int
+	Count    int
+}
+
+// districtCounts returns the number of participants and units in each
+// council and district.
+func districtCounts(participants []*model.Participant, units []model.UnitCount) []*districtCount {
+	districts := make(map[[2]string]*districtCount)
+	get := func(council, district string) *districtCount {
+		key := [2]string{council, district}
+		dc := districts[key]
+		if dc == nil {
+			dc = &districtCount{Council: council, District: district}
+			districts[key] = dc
+		}
+		return dc
+	}
+	for _, p := range participants {
+		get(p.Council, p.District).Count++
+	}
+	for _, uc := range units {
+		get(uc.Council, uc.District).Units++
+	}
+	var result []*districtCount
+	for _, dc := range districts {
+		result = append(result, dc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Council != result[j].Council {
+			return result[i].Council < result[j].Council
+		}
+		return result[i].District < result[j].District
+	})
+	return result
+}
+
+// lastYearSnapshot returns the most recent snapshot for the year before
+// the given year or nil if there is none.
+func lastYearSnapshot(snapshots []*model.RegistrationSnapshot, year int) *model.RegistrationSnapshot {
+	var result *model.RegistrationSnapshot
+	for _, s := range snapshots {
+		if s.Year == year-1 && (result == nil || s.Date > result.Date) {
+			result = s
+		}
+	}
+	return result
+}
+
+// missingUnits returns the units in the council that sent participants
+// last year and have no participants this year.
+func missingUnits(council string, lastYear []model.UnitCount, units []model.UnitCount) []model.UnitCount {
+	key := func(uc model.UnitCount) string {
+		return strings.ToLower(uc.Council + "\n" + uc.Unit)
+	}
+	current := make(map[string]bool)
+	for _, uc := range units {
+		current[key(uc)] = true
+	}
+	var result []model.UnitCount
+	for _, uc := range lastYear {
+		if uc.Council == council && !current[key(uc)] {
+			result = append(result, uc)
+		}
+	}
+	sortUnitCounts(result)
+	return result
+}
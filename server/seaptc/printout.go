@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/garyburd/web/httperror"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/pdf"
+	"github.com/seaptc/server/printout"
+)
+
+// writePDF writes the document to the response as a download.
+func (rc *requestContext) writePDF(doc *pdf.Document, name string) error {
+	rc.response.Header().Set("Content-Type", pdf.ContentType)
+	rc.response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, name))
+	_, err := doc.WriteTo(rc.response)
+	return err
+}
+
+// formsPDF returns a document with the forms for the participants in the
+// order given.
+func (a *application) formsPDF(participants []*model.Participant, conf *model.Conference, classInfo *model.ClassInfo) *pdf.Document {
+	doc := pdf.New()
+	for _, p := range participants {
+		printout.AddForm(doc, &printout.FormData{
+			Participant: p,
+			Classes:     classInfo.ParticipantSessionClasses(p),
+			Lunch:       conf.ParticipantLunch(p),
+			Conference:  conf,
+			Year:        a.conferenceDate.Year(),
+		})
+	}
+	return doc
+}
+
+// defaultStation is the print station name used when the station request
+// parameter is not set.
+const defaultStation = "main"
+
+// station returns the print station named by the station request parameter.
+func (rc *requestContext) station() string {
+	if s := rc.request.FormValue("station"); s != "" {
+		return s
+	}
+	return defaultStation
+}
+
+// labelTemplate returns the label template named by the template request
+// parameter or the named default.
+func (rc *requestContext) labelTemplate(conf *model.Conference, def string) (*model.LabelTemplate, error) {
+	name := rc.request.FormValue("template")
+	if name == "" {
+		name = def
+	}
+	t := conf.LabelTemplate(name)
+	if t == nil {
+		return nil, &httperror.Error{Status: http.StatusNotFound, Message: fmt.Sprintf("Label template %q not found.", name)}
+	}
+	return t, nil
+}
@@ -131,6 +131,8 @@ func newTemplateManager(assetDir string) *templates.Manager {
 				fileHashes.Store(s, u)
 				return u, nil
 			},
+			// dietaryClass returns Bootstrap CSS class for highlighting a dietary severity.
+			"dietaryClass": dietaryClass,
 			// isInvalid returns Bootstrap CSS class for invalid form control if k key is present in m.
 			"isInvalid": func(m map[string]string, k string) string {
 				if _, invalid := m[k]; invalid {
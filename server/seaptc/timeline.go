@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/seaptc/server/model"
+)
+
+// recordRegistrationSnapshot stores today's registration counts. Errors are
+// logged because the snapshot is not essential to the import.
+func (a *application) recordRegistrationSnapshot(ctx context.Context) {
+	participants, err := a.store.GetAllParticipants(ctx)
+	if err != nil {
+		logf(ctx, "ERROR", "error loading participants for snapshot: %v", err)
+		return
+	}
+	s := newRegistrationSnapshot(participants, time.Now(), a.conferenceDate)
+	if err := a.store.SetRegistrationSnapshot(ctx, s); err != nil {
+		logf(ctx, "ERROR", "error recording registration snapshot: %v", err)
+	}
+}
+
+const dateFormat = "2006-01-02"
+
+func newRegistrationSnapshot(participants []*model.Participant, now time.Time, conferenceDate time.Time) *model.RegistrationSnapshot {
+	now = now.In(model.TimeLocation)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, model.TimeLocation)
+	s := &model.RegistrationSnapshot{
+		Date:       now.Format(dateFormat),
+		Year:       conferenceDate.Year(),
+		DaysBefore: int(conferenceDate.Sub(today).Hours()+12) / 24,
+		Time:       now,
+		Total:      len(participants),
+	}
+
+	types := make(map[string]int)
+	programs := make(map[string]int)
+	classes := make(map[int]int)
+	for _, p := range participants {
+		types[p.Type()]++
+		programs[participantProgramCode(p)]++
+		for _, n := range p.Classes {
+			classes[n]++
+		}
+	}
+	for _, name := range timelineTypes {
+		s.Types = append(s.Types, model.RegistrationCount{Name: name, Count: types[name]})
+	}
+	for _, code := range timelinePrograms {
+		s.Programs = append(s.Programs, model.RegistrationCount{Name: code, Count: programs[code]})
+	}
+	var numbers []int
+	for n := range classes {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		s.Classes = append(s.Classes, model.RegistrationCount{Name: strconv.Itoa(n), Count: classes[n]})
+	}
+	s.Units = unitCounts(participants)
+	return s
+}
+
+// Categories in the timeline report.
+var (
+	timelineTypes    = []string{"Youth", "Adult", "Staff"}
+	timelinePrograms = func() []string {
+		var codes []string
+		for _, pd := range model.ProgramDescriptions[:model.NumPrograms] {
+			codes = append(codes, pd.Code)
+		}
+		return append(codes, "other")
+	}()
+)
+
+func participantProgramCode(p *model.Participant) string {
+	if pd := p.Program(); pd != nil {
+		return pd.Code
+	}
+	return "other"
+}
+
+// timelineDay is the cumulative number of registrations at the end of a
+// day. Counts are in the order of timelineColumns.
+type timelineDay struct {
+	Date   string
+	Counts []int
+}
+
+// timelineColumns returns the column names for timelineDay counts.
+func timelineColumns() []string {
+	columns := []string{"Total"}
+	columns = append(columns, timelineTypes...)
+	for _, code := range timelinePrograms {
+		name := "Other"
+		for _, pd := range model.ProgramDescriptions {
+			if pd.Code == code {
+				name = pd.TitleName()
+			}
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// cumulativeRegistrations returns the cumulative registrations by day using
+// the time that each participant was first imported. Participants imported
+// before the first seen time was recorded are counted on the first day.
+func cumulativeRegistrations(participants []*model.Participant) []*timelineDay {
+	columnIndex := make(map[string]int)
+	for i, name := range timelineTypes {
+		columnIndex["type:"+name] = 1 + i
+	}
+	for i, code := range timelinePrograms {
+		columnIndex["program:"+code] = 1 + len(timelineTypes) + i
+	}
+	ncolumn := 1 + len(timelineTypes) + len(timelinePrograms)
+
+	daily := make(map[string][]int)
+	var unknown []*model.Participant
+	for _, p := range participants {
+		if p.FirstSeen.IsZero() {
+			unknown = append(unknown, p)
+			continue
+		}
+		date := p.FirstSeen.In(model.TimeLocation).Format(dateFormat)
+		counts := daily[date]
+		if counts == nil {
+			counts = make([]int, ncolumn)
+			daily[date] = counts
+		}
+		counts[0]++
+		counts[columnIndex["type:"+p.Type()]]++
+		counts[columnIndex["program:"+participantProgramCode(p)]]++
+	}
+
+	var dates []string
+	for date := range daily {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	var days []*timelineDay
+	total := make([]int, ncolumn)
+	for _, p := range unknown {
+		total[0]++
+		total[columnIndex["type:"+p.Type()]]++
+		total[columnIndex["program:"+participantProgramCode(p)]]++
+	}
+	if len(dates) == 0 && len(unknown) > 0 {
+		days = append(days, &timelineDay{Date: "unknown", Counts: total})
+	}
+	for _, date := range dates {
+		counts := make([]int, ncolumn)
+		for i := range counts {
+			total[i] += daily[date][i]
+			counts[i] = total[i]
+		}
+		days = append(days, &timelineDay{Date: date, Counts: counts})
+	}
+	return days
+}
+
+// classFill is the number of participants registered in a class on each
+// sample date.
+type classFill struct {
+	Class  *model.Class
+	Counts []int
+}
+
+// Percent returns the fill rate for sample i as a percentage of capacity or
+// -1 if the class does not have a capacity.
+func (cf *classFill) Percent(i int) int {
+	if cf.Class.Capacity <= 0 {
+		return -1
+	}
+	return 100 * cf.Counts[i] / cf.Class.Capacity
+}
+
+// classFillOverTime returns the class registration counts from weekly
+// samples of this year's snapshots ending with the most recent snapshot.
+func classFillOverTime(snapshots []*model.RegistrationSnapshot, year int, classes []*model.Class) ([]string, []*classFill) {
+	var current []*model.RegistrationSnapshot
+	for _, s := range snapshots {
+		if s.Year == year {
+			current = append(current, s)
+		}
+	}
+
+	const maxSamples = 8
+	var samples []*model.RegistrationSnapshot
+	for i := len(current) - 1; i >= 0 && len(samples) < maxSamples; i-- {
+		s := current[i]
+		if len(samples) > 0 && samples[len(samples)-1].DaysBefore+7 > s.DaysBefore {
+			continue
+		}
+		samples = append(samples, s)
+	}
+
+	dates := make([]string, len(samples))
+	fills := make([]*classFill, len(classes))
+	for i, c := range classes {
+		fills[i] = &classFill{Class: c, Counts: make([]int, len(samples))}
+	}
+	for j := range samples {
+		s := samples[len(samples)-1-j]
+		dates[j] = s.Date
+		counts := make(map[string]int)
+		for _, rc := range s.Classes {
+			counts[rc.Name] = rc.Count
+		}
+		for _, f := range fills {
+			f.Counts[j] = counts[strconv.Itoa(f.Class.Number)]
+		}
+	}
+	return dates, fills
+}
+
+// yearOverYearWeek is the registration total for each year at the end of
+// a week before the conference. A negative total indicates no data.
+type yearOverYearWeek struct {
+	WeeksBefore int
+	Totals      []int
+}
+
+// yearOverYear returns the registration totals for each year by weeks
+// before the conference.
+func yearOverYear(snapshots []*model.RegistrationSnapshot) ([]int, []*yearOverYearWeek) {
+	// The latest snapshot in each week for each year.
+	latest := make(map[[2]int]*model.RegistrationSnapshot)
+	yearSet := make(map[int]bool)
+	weekSet := make(map[int]bool)
+	for _, s := range snapshots {
+		if s.DaysBefore < 0 {
+			continue
+		}
+		key := [2]int{s.Year, s.DaysBefore / 7}
+		if x := latest[key]; x == nil || s.DaysBefore < x.DaysBefore {
+			latest[key] = s
+		}
+		yearSet[s.Year] = true
+		weekSet[key[1]] = true
+	}
+
+	var years, weeks []int
+	for year := range yearSet {
+		years = append(years, year)
+	}
+	sort.Ints(years)
+	for week := range weekSet {
+		weeks = append(weeks, week)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(weeks)))
+
+	var result []*yearOverYearWeek
+	for _, week := range weeks {
+		w := &yearOverYearWeek{WeeksBefore: week}
+		for _, year := range years {
+			total := -1
+			if s := latest[[2]int{year, week}]; s != nil {
+				total = s.Total
+			}
+			w.Totals = append(w.Totals, total)
+		}
+		result = append(result, w)
+	}
+	return years, result
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/store"
+	"github.com/seaptc/server/webhook"
+)
+
+// deliveryTimeout bounds the time spent attempting the deliveries from one
+// call to notify.
+const deliveryTimeout = 2 * time.Minute
+
+// notify records a delivery of each event to each subscribed webhook and
+// makes the first attempts in the background so that the request is not
+// delayed by slow subscribers. Deliveries that are not attempted before the
+// instance shuts down and failed deliveries are retried by the cron handler.
+func (a *application) notify(ctx context.Context, events ...*webhook.Event) {
+	if len(events) == 0 {
+		return
+	}
+	hooks, err := a.store.GetCachedWebhooks(ctx)
+	if err != nil {
+		logf(ctx, "ERROR", "error getting webhooks: %v", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var deliveries []*model.WebhookDelivery
+	for _, e := range events {
+		body, err := json.Marshal(e)
+		if err != nil {
+			logf(ctx, "ERROR", "error encoding %s event: %v", e.Type, err)
+			continue
+		}
+		for _, hook := range hooks {
+			if !hook.Wants(e.Type) {
+				continue
+			}
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				WebhookID: hook.ID,
+				URL:       hook.URL,
+				Event:     e.Type,
+				EventID:   e.ID,
+				Time:      now,
+				Pending:   true,
+				// Leave time for the first attempt before the cron handler
+				// picks up the delivery.
+				NextAttempt: now.Add(deliveryTimeout),
+				Body:        string(body),
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := a.store.AddWebhookDeliveries(ctx, deliveries); err != nil {
+		logf(ctx, "ERROR", "error recording webhook deliveries: %v", err)
+		return
+	}
+
+	// The request context is canceled when the handler returns.
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), traceIDKey{}, ctx.Value(traceIDKey{})), deliveryTimeout)
+	go func() {
+		defer cancel()
+		a.attemptDeliveries(ctx, hooks, deliveries)
+	}()
+}
+
+// attemptDeliveries makes one attempt for each delivery and records the
+// result.
+func (a *application) attemptDeliveries(ctx context.Context, hooks []*model.Webhook, deliveries []*model.WebhookDelivery) {
+	hookMap := make(map[int64]*model.Webhook)
+	for _, hook := range hooks {
+		hookMap[hook.ID] = hook
+	}
+	for _, d := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if hook := hookMap[d.WebhookID]; hook == nil {
+			d.Pending = false
+			d.Error = "webhook deleted"
+		} else {
+			now := time.Now()
+			d.Attempts++
+			var err error
+			d.Status, err = webhook.DefaultDeliverer.Post(ctx, hook.URL, hook.Secret, &webhook.Event{ID: d.EventID, Type: d.Event}, []byte(d.Body))
+			d.Pending = false
+			d.Error = ""
+			if err != nil {
+				d.Error = err.Error()
+				logf(ctx, "WARNING", "webhook delivery %s to %s failed: %v", d.EventID, hook.URL, err)
+				if delay, ok := webhook.DefaultDeliverer.RetryDelay(d.Attempts, d.Status); ok {
+					d.Pending = true
+					d.NextAttempt = now.Add(delay)
+				}
+			}
+		}
+		if !d.Pending {
+			d.Body = ""
+		}
+		if err := a.store.UpdateWebhookDelivery(ctx, d); err != nil {
+			logf(ctx, "ERROR", "error recording webhook delivery: %v", err)
+		}
+	}
+}
+
+// retryWebhookDeliveries attempts the pending deliveries that are due.
+func (a *application) retryWebhookDeliveries(ctx context.Context) (int, error) {
+	deliveries, err := a.store.GetPendingWebhookDeliveries(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	due := deliveries[:0]
+	for _, d := range deliveries {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+	hooks, err := a.store.GetWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	a.attemptDeliveries(ctx, hooks, due)
+	return len(due), nil
+}
+
+// newEvents returns an event for each data value.
+func newEvents(ctx context.Context, eventType string, data ...interface{}) []*webhook.Event {
+	var events []*webhook.Event
+	for _, d := range data {
+		e, err := webhook.NewEvent(eventType, d)
+		if err != nil {
+			logf(ctx, "ERROR", "error creating %s event: %v", eventType, err)
+			continue
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// notifyParticipantImport sends the events for a participant import.
+func (a *application) notifyParticipantImport(ctx context.Context, result *store.ParticipantImport) {
+	if hooks, err := a.store.GetCachedWebhooks(ctx); err != nil || len(hooks) == 0 {
+		return
+	}
+
+	var events []*webhook.Event
+	for _, x := range []struct {
+		eventType string
+		ids       []string
+	}{
+		{model.ParticipantAddedEvent, result.Added},
+		{model.ParticipantUpdatedEvent, result.Updated},
+	} {
+		if len(x.ids) == 0 {
+			continue
+		}
+		participants, err := a.store.GetParticipantsByID(ctx, x.ids)
+		if err != nil {
+			logf(ctx, "ERROR", "error getting participants for %s event: %v", x.eventType, err)
+			continue
+		}
+		for _, p := range participants {
+			// The login code is a credential.
+			p.LoginCode = ""
+			p.MergedLoginCodes = nil
+			events = append(events, newEvents(ctx, x.eventType, p)...)
+		}
+	}
+	for _, id := range result.Deleted {
+		events = append(events, newEvents(ctx, model.ParticipantDeletedEvent, map[string]string{"id": id})...)
+	}
+	a.notify(ctx, events...)
+}
+
+// notifyClassImport sends the events for a class import.
+func (a *application) notifyClassImport(ctx context.Context, classes []*model.Class, changed []int) {
+	classMap := make(map[int]*model.Class)
+	for _, c := range classes {
+		classMap[c.Number] = c
+	}
+	var events []*webhook.Event
+	for _, number := range changed {
+		data := struct {
+			Number  int          `json:"number"`
+			Deleted bool         `json:"deleted,omitempty"`
+			Class   *model.Class `json:"class,omitempty"`
+		}{Number: number, Class: classMap[number], Deleted: classMap[number] == nil}
+		events = append(events, newEvents(ctx, model.ClassChangedEvent, &data)...)
+	}
+	a.notify(ctx, events...)
+}
+
+// notifyEvaluation sends the evaluation.submitted event.
+func (a *application) notifyEvaluation(ctx context.Context, participantID string, sessionEvals []*model.SessionEvaluation, conferenceEval *model.ConferenceEvaluation) {
+	data := struct {
+		ParticipantID        string                      `json:"participantID"`
+		SessionEvaluations   []*model.SessionEvaluation  `json:"sessionEvaluations"`
+		ConferenceEvaluation *model.ConferenceEvaluation `json:"conferenceEvaluation,omitempty"`
+	}{participantID, sessionEvals, conferenceEval}
+	a.notify(ctx, newEvents(ctx, model.EvaluationSubmittedEvent, &data)...)
+}
@@ -1,10 +1,8 @@
 package sheet
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"regexp"
 	"sort"
 	"strconv"
@@ -44,6 +42,14 @@ var setters = []struct {
 	{"all", func(c *class, s string) error { return setProgram(c, (1<<model.NumPrograms)-1, s) }},
 	{"requestedCapacity", setCapacity},
 	{"locationCapacity", setCapacity},
+	{"roomFeatures", func(c *class, s string) error { return setList(&c.RoomFeatures, strings.ToLower(s)) }},
+	{"certificate", func(c *class, s string) error { return setString(&c.Certificate, s) }},
+}
+
+// optionalColumns are columns that may be missing from the sheet.
+var optionalColumns = map[string]bool{
+	"roomFeatures": true,
+	"certificate":  true,
 }
 
 var (
@@ -122,19 +128,24 @@ func setCapacity(c *class, s string) error {
 	return nil
 }
 
-func parseClasses(r io.Reader) ([]*model.Class, error) {
-	var sheet struct {
-		Rows [][]string `json:"values"`
-	}
-	if err := json.NewDecoder(r).Decode(&sheet); err != nil {
+func parseClasses(rows [][]string) ([]*model.Class, error) {
+	var r Report
+	classes, err := parseClassRows(rows, &r)
+	if err != nil {
 		return nil, err
 	}
+	return classes, r.Err()
+}
 
-	if len(sheet.Rows) < 1 {
+// parseClassRows parses the classes sheet. Problems with class rows are
+// added to the report. A class with a bad cell is returned with the zero
+// value for the cell's field.
+func parseClassRows(rows [][]string, r *Report) ([]*model.Class, error) {
+	if len(rows) < 1 {
 		return nil, errors.New("could not find header row")
 	}
 
-	header := sheet.Rows[0]
+	header := rows[0]
 	columnIndex := map[string]int{}
 	for j, name := range header {
 		name = strings.TrimSpace(name)
@@ -143,31 +154,41 @@ func parseClasses(r io.Reader) ([]*model.Class, error) {
 		}
 	}
 	for _, s := range setters {
-		if _, ok := columnIndex[s.name]; !ok {
+		if _, ok := columnIndex[s.name]; !ok && !optionalColumns[s.name] {
 			return nil, fmt.Errorf("could not find column %s in sheet", s.name)
 		}
 	}
 
 	var result []*model.Class
-	for i := 1; i < len(sheet.Rows); i++ {
-		row := sheet.Rows[i]
+	rowNumbers := make(map[int]int)
+	for i := 1; i < len(rows); i++ {
+		row := rows[i]
 		if len(row) < 1 || !classNumberPattern.MatchString(row[0]) || row[0] == oaClassNumber {
 			continue
 		}
 		var c class
+		c.SpreadsheetRow = i + 1
 		for _, s := range setters {
-			j := columnIndex[s.name]
-			if j >= len(row) {
+			j, ok := columnIndex[s.name]
+			if !ok || j >= len(row) {
 				continue
 			}
 			cell := strings.TrimSpace(wsPattern.ReplaceAllLiteralString(row[j], " "))
 			if err := s.fn(&c, cell); err != nil {
-				return nil, fmt.Errorf("sheet (%d, %s): %v", i, s.name, err)
+				r.addError(c.SpreadsheetRow, []int{c.Number}, "sheet (%d, %s): %v", i, s.name, err)
 			}
 		}
+		if xrow, ok := rowNumbers[c.Number]; ok {
+			r.addError(c.SpreadsheetRow, []int{c.Number}, "class %d is also on row %d", c.Number, xrow)
+			continue
+		}
+		rowNumbers[c.Number] = c.SpreadsheetRow
 		start, end := c.StartEnd()
-		if start >= model.NumSession || end >= model.NumSession {
-			return nil, fmt.Errorf("class %d has bad number or length (%d)", c.Number, c.Length)
+		switch {
+		case c.Length < 1:
+			r.addError(c.SpreadsheetRow, []int{c.Number}, "class %d has bad length (%d)", c.Number, c.Length)
+		case start >= model.NumSession || end >= model.NumSession:
+			r.addError(c.SpreadsheetRow, []int{c.Number}, "class %d has bad number or length (%d)", c.Number, c.Length)
 		}
 		result = append(result, &c.Class)
 	}
@@ -0,0 +1,77 @@
+package sheet
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/xlsx"
+)
+
+// FileSource reads the planning spreadsheet from local files. See ReadRows for
+// the supported file formats.
+type FileSource struct {
+	ClassesPath string
+
+	// SuggestedScheduleRows returns ErrNoSuggestedSchedules if the path is
+	// blank.
+	SuggestedSchedulesPath string
+}
+
+// ErrNoSuggestedSchedules is returned when the source does not have
+// suggested schedules.
+var ErrNoSuggestedSchedules = errors.New("sheet: no suggested schedules")
+
+func (src *FileSource) ClassRows(ctx context.Context) ([][]string, error) {
+	return readFile(src.ClassesPath)
+}
+
+func (src *FileSource) SuggestedScheduleRows(ctx context.Context) ([][]string, error) {
+	if src.SuggestedSchedulesPath == "" {
+		return nil, ErrNoSuggestedSchedules
+	}
+	return readFile(src.SuggestedSchedulesPath)
+}
+
+func readFile(name string) ([][]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRows(name, f)
+}
+
+// ReadRows reads the rows of a sheet saved to a file. The format is
+// determined by the file name extension:
+//
+//	.csv   CSV
+//	.xlsx  Excel workbook, the first worksheet is read
+//	.json  Sheets API value range, {"values": [["a", "b"], ...]}
+func ReadRows(name string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xlsx":
+		return xlsx.ReadAll(r)
+	case ".json":
+		return decodeJSON(r)
+	default:
+		csvr := csv.NewReader(r)
+		csvr.FieldsPerRecord = -1
+		return csvr.ReadAll()
+	}
+}
+
+// ParseSuggestedSchedules parses suggested schedules from a sheet saved to a
+// file.
+func ParseSuggestedSchedules(name string, r io.Reader) ([]*model.SuggestedSchedule, error) {
+	rows, err := ReadRows(name, r)
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestedSchedules(rows)
+}
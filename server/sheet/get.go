@@ -1,7 +1,19 @@
+// Package sheet reads classes and suggested schedules from the planning
+// spreadsheet and writes class access tokens and evaluation codes back to
+// the spreadsheet.
+//
+// The spreadsheet is read from Google Sheets or from local files. Writing to
+// Google Sheets requires a service account key with the
+// https://www.googleapis.com/auth/spreadsheets scope and edit access to the
+// spreadsheet; reading requires only the spreadsheets.readonly scope.
+// Writing to a local XLSX file rewrites the workbook from the cell values,
+// so formatting, formulas and other worksheets are lost. Keep the original
+// workbook and write to a copy.
 package sheet
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 
@@ -10,6 +22,38 @@ import (
 	"golang.org/x/xerrors"
 )
 
+// Source is a source of planning spreadsheet rows. The first row of the
+// classes sheet is the header.
+type Source interface {
+	ClassRows(ctx context.Context) ([][]string, error)
+	SuggestedScheduleRows(ctx context.Context) ([][]string, error)
+}
+
+// NewSource returns the planning spreadsheet source specified in config. The
+// local files are used if set. Otherwise, the Google Sheets are used.
+func NewSource(config *model.AppConfig) Source {
+	if config.ClassesFile != "" {
+		return &FileSource{
+			ClassesPath:            config.ClassesFile,
+			SuggestedSchedulesPath: config.SuggestedSchedulesFile,
+		}
+	}
+	return &GoogleSource{Config: config}
+}
+
+// GoogleSource reads the planning spreadsheet using the Google Sheets API.
+type GoogleSource struct {
+	Config *model.AppConfig
+}
+
+func (src *GoogleSource) ClassRows(ctx context.Context) ([][]string, error) {
+	return getRows(ctx, src.Config, src.Config.ClassesSheetURL)
+}
+
+func (src *GoogleSource) SuggestedScheduleRows(ctx context.Context) ([][]string, error) {
+	return getRows(ctx, src.Config, src.Config.SuggestedSchedulesSheetURL)
+}
+
 func getBody(ctx context.Context, config *model.AppConfig, url string) (io.ReadCloser, error) {
 	jwtConfig, err := google.JWTConfigFromJSON([]byte(config.PlanningSheetServiceAccountKey), "https://www.googleapis.com/auth/spreadsheets.readonly")
 	if err != nil {
@@ -27,20 +71,36 @@ func getBody(ctx context.Context, config *model.AppConfig, url string) (io.ReadC
 	return resp.Body, nil
 }
 
-func GetClasses(ctx context.Context, config *model.AppConfig) ([]*model.Class, error) {
-	r, err := getBody(ctx, config, config.ClassesSheetURL)
+func getRows(ctx context.Context, config *model.AppConfig, url string) ([][]string, error) {
+	r, err := getBody(ctx, config, url)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Close()
-	return parseClasses(r)
+	return decodeJSON(r)
 }
 
-func GetSuggestedSchedules(ctx context.Context, config *model.AppConfig) ([]*model.SuggestedSchedule, error) {
-	r, err := getBody(ctx, config, config.SuggestedSchedulesSheetURL)
+// decodeJSON decodes the value range format returned by the Sheets API.
+func decodeJSON(r io.Reader) ([][]string, error) {
+	var sheet struct {
+		Rows [][]string `json:"values"`
+	}
+	err := json.NewDecoder(r).Decode(&sheet)
+	return sheet.Rows, err
+}
+
+func GetClasses(ctx context.Context, src Source) ([]*model.Class, error) {
+	rows, err := src.ClassRows(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Close()
-	return parseSuggestedSchedules(r)
+	return parseClasses(rows)
+}
+
+func GetSuggestedSchedules(ctx context.Context, src Source) ([]*model.SuggestedSchedule, error) {
+	rows, err := src.SuggestedScheduleRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseSuggestedSchedules(rows)
 }
@@ -1,9 +1,7 @@
 package sheet
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"strconv"
 	"strings"
 
@@ -19,17 +17,10 @@ var programs = map[string]int{
 	"Youth":        model.YouthProgram,
 }
 
-func parseSuggestedSchedules(r io.Reader) ([]*model.SuggestedSchedule, error) {
+func parseSuggestedSchedules(rows [][]string) ([]*model.SuggestedSchedule, error) {
 	var result []*model.SuggestedSchedule
 
-	var sheet struct {
-		Rows [][]string `json:"values"`
-	}
-	if err := json.NewDecoder(r).Decode(&sheet); err != nil {
-		return nil, err
-	}
-
-	for i, row := range sheet.Rows {
+	for i, row := range rows {
 		if len(row) < 3 {
 			continue
 		}
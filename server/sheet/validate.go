@@ -0,0 +1,98 @@
+package sheet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/seaptc/server/conflict"
+	"github.com/seaptc/server/model"
+)
+
+// Problem is a problem found in the planning spreadsheet.
+type Problem struct {
+	// Warning is true if the problem does not block import of the classes.
+	Warning bool
+
+	// One based spreadsheet row, zero if the problem is not specific to a
+	// row.
+	Row int
+
+	Classes []int
+	Message string
+}
+
+// Report is the list of problems found in the planning spreadsheet.
+type Report struct {
+	Problems []*Problem
+}
+
+func (r *Report) add(warning bool, row int, classes []int, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, &Problem{
+		Warning: warning,
+		Row:     row,
+		Classes: classes,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *Report) addError(row int, classes []int, format string, args ...interface{}) {
+	r.add(false, row, classes, format, args...)
+}
+
+// Errors returns the number of problems that block import.
+func (r *Report) Errors() int {
+	n := 0
+	for _, p := range r.Problems {
+		if !p.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// Warnings returns the number of problems that do not block import.
+func (r *Report) Warnings() int {
+	return len(r.Problems) - r.Errors()
+}
+
+// Err returns the first problem that blocks import as an error or nil if
+// there are no such problems.
+func (r *Report) Err() error {
+	for _, p := range r.Problems {
+		if !p.Warning {
+			return fmt.Errorf("%s", p.Message)
+		}
+	}
+	return nil
+}
+
+// ValidateClasses parses the classes sheet and reports all problems found.
+// The registered argument is the number of participants registered in each
+// class. Classes are returned even when the report has errors.
+func ValidateClasses(rows [][]string, registered map[int]int) ([]*model.Class, *Report, error) {
+	var r Report
+	classes, err := parseClassRows(rows, &r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Room double-bookings and instructors teaching two classes at once
+	// must be fixed in the sheet before the classes are imported.
+	ci := model.NewClassInfo(classes)
+	for _, c := range conflict.Classes(ci) {
+		r.addError(c.Classes[1].SpreadsheetRow, []int{c.Classes[0].Number, c.Classes[1].Number}, "%s", c)
+	}
+
+	var deleted []int
+	for number, n := range registered {
+		if n > 0 && ci.LookupNumber(number) == nil {
+			deleted = append(deleted, number)
+		}
+	}
+	sort.Ints(deleted)
+	for _, number := range deleted {
+		r.addError(0, []int{number}, "class %d has %d registered participants and is missing from the sheet", number, registered[number])
+	}
+
+	return classes, &r, nil
+}
@@ -0,0 +1,254 @@
+package sheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/seaptc/server/model"
+	"github.com/seaptc/server/xlsx"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/xerrors"
+)
+
+// CodeWriter is implemented by sources that can write class access tokens
+// and evaluation codes back to the planning spreadsheet.
+type CodeWriter interface {
+	// WriteCodes updates the accessToken and evaluationCodes cells of the
+	// class rows and returns the number of cells changed.
+	WriteCodes(ctx context.Context, classes []*model.Class) (int, error)
+}
+
+var codeColumns = []struct {
+	name  string
+	value func(*model.Class) string
+}{
+	{"accessToken", func(c *model.Class) string { return c.AccessToken }},
+	{"evaluationCodes", func(c *model.Class) string { return c.EvaluationCodes }},
+}
+
+type cellUpdate struct {
+	row, column int // zero based indices in rows
+	value       string
+}
+
+// codeUpdates returns the cells in rows that differ from the class codes.
+// Rows are located using Class.SpreadsheetRow or by searching the number
+// column if the sheet was edited since the classes were loaded.
+func codeUpdates(rows [][]string, classes []*model.Class) ([]cellUpdate, error) {
+	if len(rows) < 1 {
+		return nil, errors.New("could not find header row")
+	}
+	columnIndex := map[string]int{}
+	for j, name := range rows[0] {
+		columnIndex[strings.TrimSpace(name)] = j
+	}
+	for _, name := range []string{"number", "accessToken", "evaluationCodes"} {
+		if _, ok := columnIndex[name]; !ok {
+			return nil, fmt.Errorf("could not find column %s in sheet", name)
+		}
+	}
+
+	cell := func(i, j int) string {
+		if j >= len(rows[i]) {
+			return ""
+		}
+		return strings.TrimSpace(rows[i][j])
+	}
+
+	rowIndex := map[string]int{}
+	for i := 1; i < len(rows); i++ {
+		rowIndex[cell(i, columnIndex["number"])] = i
+	}
+
+	var updates []cellUpdate
+	for _, c := range classes {
+		number := strconv.Itoa(c.Number)
+		i := c.SpreadsheetRow - 1
+		if i < 1 || i >= len(rows) || cell(i, columnIndex["number"]) != number {
+			// The sheet was edited after the class was loaded.
+			var ok bool
+			i, ok = rowIndex[number]
+			if !ok {
+				return nil, fmt.Errorf("class %d not found in sheet", c.Number)
+			}
+		}
+		for _, cc := range codeColumns {
+			j := columnIndex[cc.name]
+			if v := cc.value(c); v != cell(i, j) {
+				updates = append(updates, cellUpdate{row: i, column: j, value: v})
+			}
+		}
+	}
+	return updates, nil
+}
+
+var (
+	sheetsURLPattern = regexp.MustCompile(`/spreadsheets/([^/]+)/values/([^/?]+)`)
+	cellRefPattern   = regexp.MustCompile(`^([A-Za-z]*)(\d*)`)
+)
+
+// parseValuesURL returns the spreadsheet ID, the sheet name, and the zero
+// based column and row of the first cell in the range for a Sheets API
+// values URL.
+func parseValuesURL(u string) (id string, sheetName string, column int, row int, err error) {
+	m := sheetsURLPattern.FindStringSubmatch(u)
+	if m == nil {
+		return "", "", 0, 0, fmt.Errorf("sheet: could not parse spreadsheet ID and range from %s", u)
+	}
+	id = m[1]
+	r, err := url.PathUnescape(m[2])
+	if err != nil {
+		return "", "", 0, 0, err
+	}
+	sheetName, start := r, ""
+	if i := strings.LastIndexByte(r, '!'); i >= 0 {
+		sheetName, start = r[:i], r[i+1:]
+	}
+	m = cellRefPattern.FindStringSubmatch(start)
+	if m[1] != "" {
+		for _, b := range strings.ToUpper(m[1]) {
+			column = column*26 + int(b-'A') + 1
+		}
+		column--
+	}
+	if m[2] != "" {
+		row, _ = strconv.Atoi(m[2])
+		row--
+	}
+	return id, sheetName, column, row, nil
+}
+
+func (src *GoogleSource) WriteCodes(ctx context.Context, classes []*model.Class) (int, error) {
+	rows, err := src.ClassRows(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updates, err := codeUpdates(rows, classes)
+	if err != nil || len(updates) == 0 {
+		return 0, err
+	}
+
+	id, sheetName, column, row, err := parseValuesURL(src.Config.ClassesSheetURL)
+	if err != nil {
+		return 0, err
+	}
+	if !strings.HasPrefix(sheetName, "'") {
+		sheetName = "'" + strings.Replace(sheetName, "'", "''", -1) + "'"
+	}
+
+	type valueRange struct {
+		Range  string     `json:"range"`
+		Values [][]string `json:"values"`
+	}
+	request := struct {
+		ValueInputOption string        `json:"valueInputOption"`
+		Data             []*valueRange `json:"data"`
+	}{
+		// Store values as entered to preserve leading zeros.
+		ValueInputOption: "RAW",
+	}
+	for _, u := range updates {
+		request.Data = append(request.Data, &valueRange{
+			Range:  fmt.Sprintf("%s!%s%d", sheetName, xlsx.ColumnName(column+u.column), row+u.row+1),
+			Values: [][]string{{u.value}},
+		})
+	}
+	body, err := json.Marshal(&request)
+	if err != nil {
+		return 0, err
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON([]byte(src.Config.PlanningSheetServiceAccountKey), "https://www.googleapis.com/auth/spreadsheets")
+	if err != nil {
+		return 0, xerrors.Errorf("error parsing planning sheet service account key: %w", err)
+	}
+	resp, err := jwtConfig.Client(ctx).Post(
+		fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values:batchUpdate", id),
+		"application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		p, _ := ioutil.ReadAll(resp.Body)
+		return 0, fmt.Errorf("update sheet returned %d: %s", resp.StatusCode, bytes.TrimSpace(p))
+	}
+	return len(updates), nil
+}
+
+// WriteCodes rewrites the classes file with the updated codes. An XLSX file
+// is rewritten from the cell values, losing formatting, formulas and other
+// worksheets.
+func (src *FileSource) WriteCodes(ctx context.Context, classes []*model.Class) (int, error) {
+	rows, err := src.ClassRows(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updates, err := codeUpdates(rows, classes)
+	if err != nil || len(updates) == 0 {
+		return 0, err
+	}
+	for _, u := range updates {
+		for len(rows[u.row]) <= u.column {
+			rows[u.row] = append(rows[u.row], "")
+		}
+		rows[u.row][u.column] = u.value
+	}
+	return len(updates), writeFile(src.ClassesPath, rows)
+}
+
+// writeFile writes rows to a file in the format used by ReadRows.
+func writeFile(name string, rows [][]string) error {
+	var buf bytes.Buffer
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xlsx":
+		var columns []xlsx.Column
+		for _, row := range rows {
+			for len(columns) < len(row) {
+				columns = append(columns, xlsx.Column{})
+			}
+		}
+		for j, name := range rows[0] {
+			columns[j].Name = name
+		}
+		w := xlsx.NewWriter("", columns)
+		for _, row := range rows[1:] {
+			values := make([]interface{}, len(row))
+			for j, v := range row {
+				values[j] = v
+			}
+			w.Write(values...)
+		}
+		if _, err := w.WriteTo(&buf); err != nil {
+			return err
+		}
+	case ".json":
+		if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"values": rows}); err != nil {
+			return err
+		}
+	default:
+		w := csv.NewWriter(&buf)
+		w.WriteAll(rows)
+		if err := w.Error(); err != nil {
+			return err
+		}
+	}
+
+	// Write to temporary file and rename to avoid a partial file on error.
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
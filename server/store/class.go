@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/seaptc/server/model"
 
@@ -52,21 +53,25 @@ func (store *Store) GetAllClassesFull(ctx context.Context) ([]*model.Class, erro
 	return classes, err
 }
 
-func (store *Store) ImportClasses(ctx context.Context, classes []*model.Class) (int, error) {
+// ImportClasses replaces the classes in the datastore with the given
+// classes. The numbers of the added, modified and deleted classes are
+// returned.
+func (store *Store) ImportClasses(ctx context.Context, classes []*model.Class) ([]int, error) {
 	if len(classes) < 20 {
-		return 0, fmt.Errorf("store: more classes expected for update")
+		return nil, fmt.Errorf("store: more classes expected for update")
 	}
 
 	for _, c := range classes {
 		if !model.IsValidClassNumber(c.Number) {
-			return 0, fmt.Errorf("invalid class number %d", c.Number)
+			return nil, fmt.Errorf("invalid class number %d", c.Number)
 		}
 	}
 
-	var mutationCount int
+	var changed []int
 
 	_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
 
+		changed = changed[:0]
 		xhashes := make(map[int]string)
 
 		// Step 1: Query for import field hash values.
@@ -95,6 +100,7 @@ func (store *Store) ImportClasses(ctx context.Context, classes []*model.Class) (
 				// New class.
 				c.ImportHash = hash
 				mutations = append(mutations, datastore.NewInsert(classKey(c.Number), c))
+				changed = append(changed, c.Number)
 				continue
 			}
 			delete(xhashes, c.Number)
@@ -106,19 +112,26 @@ func (store *Store) ImportClasses(ctx context.Context, classes []*model.Class) (
 			if err := tx.Get(key, &xc); err != nil {
 				return err
 			}
+			// The hash also changes when fields are added to the import
+			// fields. Store the new hash, but report the class as changed
+			// only if an import field changed.
 			xc.ImportHash = hash
+			equal := c.EqualImportFields(&xc)
 			c.CopyImportFieldsTo(&xc)
 			mutations = append(mutations, datastore.NewUpdate(key, &xc))
+			if !equal {
+				changed = append(changed, c.Number)
+			}
 		}
 
 		// Step 3: Delete classes missing from the imported data.
 
 		for number := range xhashes {
 			mutations = append(mutations, datastore.NewDelete(classKey(number)))
+			changed = append(changed, number)
 		}
 
-		mutationCount = len(mutations)
-		if mutationCount == 0 {
+		if len(mutations) == 0 {
 			return nil
 		}
 
@@ -127,7 +140,11 @@ func (store *Store) ImportClasses(ctx context.Context, classes []*model.Class) (
 	})
 
 	store.classInfoCache.clear()
-	return mutationCount, err
+	if err != nil {
+		return nil, err
+	}
+	sort.Ints(changed)
+	return changed, nil
 }
 
 // UpdateClasses gets and puts all entities. Use when adding new indexed fields to the entity.
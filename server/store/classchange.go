@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"cloud.google.com/go/datastore"
+	"github.com/seaptc/server/model"
+)
+
+const classChangeKind = "classChange"
+
+func classChangeKey(id int64) *datastore.Key {
+	return datastore.IDKey(classChangeKind, id, conferenceEntityGroupKey)
+}
+
+func (store *Store) AddClassChange(ctx context.Context, cc *model.ClassChange) error {
+	key, err := store.dsClient.Put(ctx, datastore.IncompleteKey(classChangeKind, conferenceEntityGroupKey), cc)
+	if err != nil {
+		return err
+	}
+	cc.ID = key.ID
+	return nil
+}
+
+// GetClassChanges returns the class change requests with the given status,
+// oldest first.
+func (store *Store) GetClassChanges(ctx context.Context, status string) ([]*model.ClassChange, error) {
+	return store.getClassChanges(ctx, datastore.NewQuery(classChangeKind).Ancestor(conferenceEntityGroupKey).
+		Filter(model.ClassChange_Status+"=", status))
+}
+
+// GetParticipantClassChanges returns the participant's class change
+// requests, oldest first.
+func (store *Store) GetParticipantClassChanges(ctx context.Context, participantID string) ([]*model.ClassChange, error) {
+	return store.getClassChanges(ctx, datastore.NewQuery(classChangeKind).Ancestor(conferenceEntityGroupKey).
+		Filter(model.ClassChange_ParticipantID+"=", participantID))
+}
+
+func (store *Store) getClassChanges(ctx context.Context, q *datastore.Query) ([]*model.ClassChange, error) {
+	var changes []*model.ClassChange
+	_, err := store.dsClient.GetAll(ctx, q, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Created.Before(changes[j].Created) })
+	return changes, err
+}
+
+// UpdateClassChange calls update to modify the request in a transaction.
+func (store *Store) UpdateClassChange(ctx context.Context, id int64, update func(*model.ClassChange) error) error {
+	return store.updateEntity(ctx, classChangeKey(id), func(cc *model.ClassChange) error {
+		if cc.Created.IsZero() {
+			return ErrNotFound
+		}
+		return update(cc)
+	})
+}
+
+// ErrClassChangeDecided is returned by DecideClassChange when the request
+// is not pending.
+var ErrClassChangeDecided = errors.New("class change request already decided")
+
+// DecideClassChange calls decide to modify a pending request and the
+// requesting participant in a transaction. The participant is nil if not
+// found. The participant is saved only if decide approves the request.
+// ErrClassChangeDecided is returned with the request if the request is not
+// pending.
+func (store *Store) DecideClassChange(ctx context.Context, id int64, decide func(*model.ClassChange, *model.Participant) error) (*model.ClassChange, error) {
+	var cc model.ClassChange
+	_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		cc = model.ClassChange{}
+		if err := tx.Get(classChangeKey(id), &cc); err != nil {
+			return err
+		}
+		if !cc.IsPending() {
+			return ErrClassChangeDecided
+		}
+		var p model.Participant
+		if err := noEntityOK(tx.Get(participantKey(cc.ParticipantID), &p)); err != nil {
+			return err
+		}
+		pp := &p
+		if p.ID == "" {
+			pp = nil
+		}
+		if err := decide(&cc, pp); err != nil {
+			return err
+		}
+		if _, err := tx.Put(classChangeKey(id), &cc); err != nil {
+			return err
+		}
+		if pp != nil && cc.Status == model.ClassChangeApproved {
+			_, err := tx.Put(participantKey(p.ID), &p)
+			return err
+		}
+		return nil
+	})
+	return &cc, err
+}
@@ -209,3 +209,36 @@ func (store *Store) GetAllEvaluationStatus(ctx context.Context) (map[string]*Eva
 
 	return result, nil
 }
+
+// moveEvaluations moves the evaluations of participant fromID to participant
+// toID in transaction tx. Evaluations that toID already has for the session
+// or conference are kept and the evaluations of fromID are deleted.
+func moveEvaluations(tx *datastore.Transaction, fromID, toID string) error {
+	move := func(from, to *datastore.Key, e interface{}) error {
+		err := tx.Get(from, e)
+		if err == datastore.ErrNoSuchEntity {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var x datastore.PropertyList
+		err = tx.Get(to, &x)
+		if err == datastore.ErrNoSuchEntity {
+			if _, err := tx.Put(to, e); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		return tx.Delete(from)
+	}
+
+	for session := 0; session < model.NumSession; session++ {
+		var e model.SessionEvaluation
+		if err := move(sessionEvaluationKey(fromID, session), sessionEvaluationKey(toID, session), &e); err != nil {
+			return err
+		}
+	}
+	var e model.ConferenceEvaluation
+	return move(conferenceEvaluationKey(fromID), conferenceEvaluationKey(toID), &e)
+}
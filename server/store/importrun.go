@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+	"github.com/seaptc/server/model"
+)
+
+const importRunKind = "importRun"
+
+func (store *Store) AddImportRun(ctx context.Context, run *model.ImportRun) error {
+	_, err := store.dsClient.Put(ctx, datastore.IncompleteKey(importRunKind, conferenceEntityGroupKey), run)
+	return err
+}
+
+// GetImportRuns returns the most recent import runs.
+func (store *Store) GetImportRuns(ctx context.Context, limit int) ([]*model.ImportRun, error) {
+	var runs []*model.ImportRun
+	_, err := store.dsClient.GetAll(ctx,
+		datastore.NewQuery(importRunKind).Ancestor(conferenceEntityGroupKey).Order("-"+model.ImportRun_Time).Limit(limit),
+		&runs)
+	return runs, err
+}
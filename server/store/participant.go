@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/seaptc/server/model"
 	"golang.org/x/sync/errgroup"
@@ -62,6 +63,20 @@ type participantΠImportHashLoginCode struct {
 	LoginCode  string `datastore:"loginCode"`
 }
 
+// participantΠMerged is used as the destination type for project(merged
+// IDs) and project(merged login codes).
+type participantΠMerged struct {
+	// Array proparties are returned as single elements in project queries.
+	ID        string `datastore:"mergedIDs"`
+	LoginCode string `datastore:"mergedLoginCodes"`
+}
+
+// participantΠAllergies is used as the destination type for
+// project(allergies).
+type participantΠAllergies struct {
+	Allergies string `datastore:"allergies"`
+}
+
 func (store *Store) GetParticipant(ctx context.Context, id string) (*model.Participant, error) {
 	var p model.Participant
 	err := store.dsClient.Get(ctx, participantKey(id), &p)
@@ -79,12 +94,74 @@ func (store *Store) GetParticipantForLoginCode(ctx context.Context, loginCode st
 	if err != nil {
 		return nil, err
 	}
+	if len(participants) == 0 {
+		// Try the login codes of merged duplicates.
+		_, err = store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).
+			Ancestor(conferenceEntityGroupKey).
+			Filter(model.Participant_MergedLoginCodes+"=", loginCode), &participants)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(participants) != 1 {
 		return nil, ErrNotFound
 	}
 	return participants[0], nil
 }
 
+// addMergedLoginCodes adds the login codes of merged duplicates to codes so
+// that the codes are not allocated again.
+func (store *Store) addMergedLoginCodes(ctx context.Context, codes map[string]bool) error {
+	var values []participantΠMerged
+	// No ancestor in query for use of built-in index.
+	_, err := store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Project(model.Participant_MergedLoginCodes), &values)
+	for _, v := range values {
+		codes[v.LoginCode] = true
+	}
+	return err
+}
+
+// importParticipantIDs returns the participants to import and the ID of
+// each participant. A registration of a duplicate merged by
+// MergeParticipants is imported to the surviving participant. The
+// registration is dropped if the survivor's own registration is also
+// imported.
+func (store *Store) importParticipantIDs(ctx context.Context, participants []*model.Participant) ([]*model.Participant, map[*model.Participant]string, error) {
+	var values []participantΠMerged
+	// No ancestor in query for use of built-in index.
+	keys, err := store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Project(model.Participant_MergedIDs), &values)
+	if err != nil {
+		return nil, nil, err
+	}
+	survivors := make(map[string]string)
+	for i, k := range keys {
+		survivors[values[i].ID] = k.Name
+	}
+
+	ids := make(map[*model.Participant]string)
+	used := make(map[string]bool)
+	for _, p := range participants {
+		id := participantID(p)
+		if _, ok := survivors[id]; !ok {
+			ids[p] = id
+			used[id] = true
+		}
+	}
+	var result []*model.Participant
+	for _, p := range participants {
+		if _, ok := ids[p]; !ok {
+			id := survivors[participantID(p)]
+			if used[id] {
+				continue
+			}
+			ids[p] = id
+			used[id] = true
+		}
+		result = append(result, p)
+	}
+	return result, ids, nil
+}
+
 func (store *Store) GetParticipantsByID(ctx context.Context, ids []string) ([]*model.Participant, error) {
 	keys := make([]*datastore.Key, len(ids))
 	for i, id := range ids {
@@ -146,7 +223,8 @@ var allParticipantsQuery = datastore.NewQuery(participantKind).Ancestor(conferen
 	model.Participant_StaffRole,
 	model.Participant_Youth,
 	model.Participant_PrintForm,
-	model.Participant_DietaryRestrictions)
+	model.Participant_DietaryRestrictions,
+	model.Participant_Allergies)
 
 func (store *Store) GetAllParticipants(ctx context.Context) ([]*model.Participant, error) {
 
@@ -251,25 +329,108 @@ func joinComma(p []string, max int) string {
 	return fmt.Sprintf("%s and %d more", strings.Join(p[:max-1], ", "), len(p)-max+1)
 }
 
-func (store *Store) ImportParticipants(ctx context.Context, participants []*model.Participant) (string, error) {
+// ParticipantImport describes the changes made by ImportParticipants.
+type ParticipantImport struct {
+	Summary string
+
+	// IDs of the added, updated and deleted participants.
+	Added   []string
+	Updated []string
+	Deleted []string
+}
+
+// ImportDeletions returns the number of participants in the datastore and
+// the IDs of the participants that ImportParticipants would delete when
+// importing participants. Provisional walk-in records are not counted as
+// deletions.
+func (store *Store) ImportDeletions(ctx context.Context, participants []*model.Participant) (int, []string, error) {
+	participants, ids, err := store.importParticipantIDs(ctx, participants)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		g              errgroup.Group
+		keys, provKeys []*datastore.Key
+	)
+	g.Go(func() error {
+		var err error
+		keys, err = store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).KeysOnly(), nil)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		provKeys, err = store.dsClient.GetAll(ctx,
+			datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).Filter(model.Participant_Provisional+"=", true).KeysOnly(),
+			nil)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return 0, nil, err
+	}
+
+	keep := make(map[string]bool)
+	for _, p := range participants {
+		keep[ids[p]] = true
+	}
+	for _, k := range provKeys {
+		keep[k.Name] = true
+	}
+	var deleted []string
+	for _, k := range keys {
+		if !keep[k.Name] {
+			deleted = append(deleted, k.Name)
+		}
+	}
+	return len(keys), deleted, nil
+}
+
+func (store *Store) ImportParticipants(ctx context.Context, participants []*model.Participant) (*ParticipantImport, error) {
+
+	participants, ids, err := store.importParticipantIDs(ctx, participants)
+	if err != nil {
+		return nil, err
+	}
 
 	hashes := make(map[string]string)
 	for _, p := range participants {
-		hashes[participantID(p)] = p.HashImportFields()
+		hashes[ids[p]] = p.HashImportFields()
+	}
+
+	// Match provisional walk-in records to registration records.
+	var provisional []*model.Participant
+	_, err = store.dsClient.GetAll(ctx,
+		datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).Filter(model.Participant_Provisional+"=", true),
+		&provisional)
+	if err != nil {
+		return nil, err
+	}
+	reconcile := make(map[string]string)
+	for _, x := range provisional {
+		for _, p := range participants {
+			if x.SameNameAndEmail(p) {
+				reconcile[x.ID] = ids[p]
+				break
+			}
+		}
 	}
 
 	var allAdds, allUpdates []string
+	var result ParticipantImport
 	var xhashes map[string]string
+	now := time.Now()
 
 	for len(participants) > 0 {
 
-		var adds, updates []string
+		var adds, updates, addIDs, updateIDs []string
 		var offset int
 
 		_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
 
 			adds = adds[:0]
 			updates = updates[:0]
+			addIDs = addIDs[:0]
+			updateIDs = updateIDs[:0]
 
 			// Query for import field hash values and login codes
 
@@ -287,6 +448,9 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 				xhashes[k.Name] = hashCodeValues[i].ImportHash
 				codes[hashCodeValues[i].LoginCode] = true
 			}
+			if err := store.addMergedLoginCodes(ctx, codes); err != nil {
+				return err
+			}
 
 			// For each participanti, insert or update as needed...
 
@@ -294,7 +458,7 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 
 			for offset = 0; offset < len(participants) && len(mutations) < maxMutationsPerCall; offset++ {
 				p := participants[offset]
-				id := participantID(p)
+				id := ids[p]
 				hash := hashes[id]
 				xhash := xhashes[id]
 				if hash == xhash {
@@ -307,12 +471,15 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 					// Participant not in datastore, insert.
 					p.ImportHash = hash
 					p.PrintForm = true
+					p.FirstSeen = now
+					p.LastChanged = now
 					p.LoginCode, err = allocateUniqueLoginCode(codes)
 					if err != nil {
 						return err
 					}
 					mutations = append(mutations, datastore.NewInsert(key, p))
 					adds = append(adds, p.LastName)
+					addIDs = append(addIDs, id)
 					continue
 				} else {
 					// Participant is in datastore, update.
@@ -320,11 +487,22 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 					if err := tx.Get(key, &xp); err != nil {
 						return err
 					}
+					// The hash also changes when fields are added to
+					// the import fields. Store the new hash, but report
+					// the update only if the participant changed.
+					x := xp
+					x.Overrides = append([]model.ParticipantOverride(nil), xp.Overrides...)
 					xp.ImportHash = hash
-					xp.PrintForm = xp.PrintForm || !p.EqualPrintFields(&xp)
-					p.CopyImportFieldsTo(&xp)
+					xp.PrintForm = xp.MergeImport(p) || xp.PrintForm
+					changed := !xp.EqualImportFields(&x) || !equalOverrides(xp.Overrides, x.Overrides)
+					if changed {
+						xp.LastChanged = now
+					}
 					mutations = append(mutations, datastore.NewUpdate(key, &xp))
-					updates = append(updates, p.LastName)
+					if changed {
+						updates = append(updates, p.LastName)
+						updateIDs = append(updateIDs, id)
+					}
 				}
 			}
 
@@ -333,19 +511,40 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 		})
 
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		participants = participants[offset:]
 		allAdds = append(allAdds, adds...)
 		allUpdates = append(allUpdates, updates...)
+		result.Added = append(result.Added, addIDs...)
+		result.Updated = append(result.Updated, updateIDs...)
 	}
 
-	// Find particpants to delete.
+	// Replace matched provisional records with the registration records.
+
+	var reconciled []string
+	for _, x := range provisional {
+		id, ok := reconcile[x.ID]
+		if !ok {
+			continue
+		}
+		if err := store.reconcileProvisional(ctx, x.ID, id); err != nil {
+			return nil, err
+		}
+		reconciled = append(reconciled, x.LastName)
+		result.Deleted = append(result.Deleted, x.ID)
+	}
+
+	// Find particpants to delete. Provisional records are kept until
+	// reconciled.
 
 	for id := range hashes {
 		delete(xhashes, id)
 	}
+	for _, x := range provisional {
+		delete(xhashes, x.ID)
+	}
 
 	/*
 		const deleteLimit = 20
@@ -356,8 +555,9 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 
 	for id := range xhashes {
 		if err := noEntityOK(store.dsClient.Delete(ctx, participantKey(id))); err != nil {
-			return "", err
+			return nil, err
 		}
+		result.Deleted = append(result.Deleted, id)
 	}
 
 	// Create summary of the change.
@@ -371,9 +571,148 @@ func (store *Store) ImportParticipants(ctx context.Context, participants []*mode
 	if len(xhashes) > 0 {
 		parts = append(parts, fmt.Sprintf("Deleted %d", len(xhashes)))
 	}
-	summary := strings.Join(parts, "; ")
+	if len(reconciled) > 0 {
+		parts = append(parts, fmt.Sprintf("Reconciled walk-ins %s", joinComma(reconciled, 5)))
+	}
+	result.Summary = strings.Join(parts, "; ")
+
+	return &result, nil
+}
+
+func equalOverrides(a, b []model.ParticipantOverride) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileProvisional replaces the provisional walk-in record with the
+// registration record. The check-in, notes and evaluations of the walk-in
+// are moved to the registration record. The walk-in's login code is recorded
+// as a merged login code so that both codes work. The form is queued for
+// printing if the printed fields changed.
+func (store *Store) reconcileProvisional(ctx context.Context, provisionalID string, id string) error {
+	_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var x, p model.Participant
+		if err := tx.Get(participantKey(provisionalID), &x); err != nil {
+			return noEntityOK(err)
+		}
+		if err := tx.Get(participantKey(id), &p); err != nil {
+			return err
+		}
+		p.AddMergedLoginCode(x.LoginCode)
+		if p.FirstSeen.IsZero() || (!x.FirstSeen.IsZero() && x.FirstSeen.Before(p.FirstSeen)) {
+			p.FirstSeen = x.FirstSeen
+		}
+		if p.CheckinTime.IsZero() {
+			p.CheckinTime = x.CheckinTime
+		}
+		p.Notes = strings.TrimSpace(p.Notes + "\n" + x.Notes)
+		p.PrintForm = x.PrintForm || !p.EqualPrintFields(&x)
+		if _, err := tx.Put(participantKey(id), &p); err != nil {
+			return err
+		}
+		if err := moveEvaluations(tx, provisionalID, id); err != nil {
+			return err
+		}
+		return tx.Delete(participantKey(provisionalID))
+	})
+	return err
+}
+
+// MergeParticipants merges the duplicate participant into the surviving
+// participant and deletes the duplicate. The evaluations, notes, instructor
+// classes and profile edits of the duplicate are moved to the survivor. The
+// duplicate's ID and login code are recorded on the survivor so that imports
+// of the duplicate's registration update the survivor and the duplicate's
+// login code continues to work.
+func (store *Store) MergeParticipants(ctx context.Context, survivorID string, duplicateID string) error {
+	if survivorID == duplicateID {
+		return errors.New("cannot merge participant with itself")
+	}
+	_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var p, d model.Participant
+		if err := tx.GetMulti([]*datastore.Key{participantKey(survivorID), participantKey(duplicateID)}, []*model.Participant{&p, &d}); err != nil {
+			if errs, ok := err.(datastore.MultiError); ok {
+				for _, err := range errs {
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return err
+		}
+		x := p
+		x.InstructorClasses = append([]model.InstructorClass(nil), p.InstructorClasses...)
+		p.MergeDuplicate(&d, time.Now())
+		p.PrintForm = p.PrintForm || !p.EqualPrintFields(&x)
+		if _, err := tx.Put(participantKey(survivorID), &p); err != nil {
+			return err
+		}
+		if err := moveEvaluations(tx, duplicateID, survivorID); err != nil {
+			return err
+		}
+		return tx.Delete(participantKey(duplicateID))
+	})
+	return err
+}
+
+// ErrParticipantExists is returned by AddProvisionalParticipant when the
+// walk-in was already added.
+var ErrParticipantExists = errors.New("participant exists")
+
+// AddProvisionalParticipant adds a walk-in participant. The participant is
+// assigned an ID and login code and is queued for printing.
+func (store *Store) AddProvisionalParticipant(ctx context.Context, p *model.Participant) error {
+	now := time.Now()
+	p.RegistrationNumber = "walk-in"
+	p.Provisional = true
+	p.PrintForm = true
+	p.FirstSeen = now
+	p.LastChanged = now
+	id := participantID(p)
+	key := participantKey(id)
+	_, err := store.dsClient.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var xp model.Participant
+		err := tx.Get(key, &xp)
+		switch {
+		case err == nil:
+			return ErrParticipantExists
+		case err != datastore.ErrNoSuchEntity:
+			return err
+		}
 
-	return summary, nil
+		var values []participantΠImportHashLoginCode
+		_, err = store.dsClient.GetAll(ctx,
+			datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).Project(model.Participant_ImportHash, model.Participant_LoginCode),
+			&values)
+		if err != nil {
+			return err
+		}
+		codes := make(map[string]bool)
+		for _, v := range values {
+			codes[v.LoginCode] = true
+		}
+		if err := store.addMergedLoginCodes(ctx, codes); err != nil {
+			return err
+		}
+		p.LoginCode, err = allocateUniqueLoginCode(codes)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Put(key, p)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	p.ID = id
+	return nil
 }
 
 func equalInstructorClasses(a []model.InstructorClass, b []model.InstructorClass) bool {
@@ -398,6 +737,17 @@ func (store *Store) SetInstructorClasses(ctx context.Context, participantID stri
 	})
 }
 
+// UpdateParticipant calls update to modify the participant in a transaction.
+func (store *Store) UpdateParticipant(ctx context.Context, participantID string, update func(*model.Participant) error) error {
+	key := participantKey(participantID)
+	return store.updateEntity(ctx, key, func(xp *model.Participant) error {
+		if xp.ID == "" {
+			return ErrNotFound
+		}
+		return update(xp)
+	})
+}
+
 func (store *Store) SetNotesNoShow(ctx context.Context, participantID, notes string, noShow bool) error {
 	key := participantKey(participantID)
 	return store.updateEntity(ctx, key, func(xp *model.Participant) error {
@@ -407,6 +757,24 @@ func (store *Store) SetNotesNoShow(ctx context.Context, participantID, notes str
 	})
 }
 
+// CheckinParticipant records the participant's arrival at the conference.
+// The returned time is the time of the first checkin.
+func (store *Store) CheckinParticipant(ctx context.Context, participantID string, t time.Time) (time.Time, error) {
+	key := participantKey(participantID)
+	err := store.updateEntity(ctx, key, func(xp *model.Participant) error {
+		if xp.ID == "" {
+			return ErrNotFound
+		}
+		if !xp.CheckinTime.IsZero() {
+			t = xp.CheckinTime
+			return errNoUpdate
+		}
+		xp.CheckinTime = t
+		return nil
+	})
+	return t, err
+}
+
 func (store *Store) SetParticipantsPrintForm(ctx context.Context, participantIDs []string, printForm bool) (int, error) {
 	keys := make([]*datastore.Key, len(participantIDs))
 	for i, id := range participantIDs {
@@ -421,6 +789,47 @@ func (store *Store) SetParticipantsPrintForm(ctx context.Context, participantIDs
 	})
 }
 
+// MigrateParticipants rewrites participants stored before the allergies
+// field was added. Participants without the field are missing from the
+// participant list query. The import hash is not changed. MigrateParticipants
+// returns the number of participants rewritten.
+func (store *Store) MigrateParticipants(ctx context.Context) (int, error) {
+	var (
+		g           errgroup.Group
+		keys, xkeys []*datastore.Key
+		allergies   []participantΠAllergies
+	)
+	g.Go(func() error {
+		var err error
+		keys, err = store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).KeysOnly(), nil)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		// No ancestor in query for use of built-in index.
+		xkeys, err = store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Project(model.Participant_Allergies), &allergies)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return 0, err
+	}
+
+	migrated := make(map[string]bool)
+	for _, k := range xkeys {
+		migrated[k.Name] = true
+	}
+	var missing []*datastore.Key
+	for _, k := range keys {
+		if !migrated[k.Name] {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+	return store.updateEntities(ctx, missing, func(*model.Participant) error { return nil })
+}
+
 // UpdateParticipants gets and puts all entities. Use when adding new indexed fields to the entity.
 func (store *Store) UpdateParticipants(ctx context.Context) error {
 	keys, err := store.dsClient.GetAll(ctx, datastore.NewQuery(participantKind).Ancestor(conferenceEntityGroupKey).KeysOnly(), nil)
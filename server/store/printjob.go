@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+	"github.com/seaptc/server/model"
+)
+
+const printJobKind = "printJob"
+
+func printJobKey(id int64) *datastore.Key {
+	return datastore.IDKey(printJobKind, id, conferenceEntityGroupKey)
+}
+
+func (store *Store) AddPrintJob(ctx context.Context, job *model.PrintJob) error {
+	key, err := store.dsClient.Put(ctx, datastore.IncompleteKey(printJobKind, conferenceEntityGroupKey), job)
+	if err != nil {
+		return err
+	}
+	job.ID = key.ID
+	return nil
+}
+
+func (store *Store) GetPrintJob(ctx context.Context, id int64) (*model.PrintJob, error) {
+	var job model.PrintJob
+	err := store.dsClient.Get(ctx, printJobKey(id), &job)
+	return &job, err
+}
+
+// GetPrintJobs returns the most recent print jobs. All jobs are returned if
+// limit is negative.
+func (store *Store) GetPrintJobs(ctx context.Context, limit int) ([]*model.PrintJob, error) {
+	var jobs []*model.PrintJob
+	_, err := store.dsClient.GetAll(ctx,
+		datastore.NewQuery(printJobKind).Ancestor(conferenceEntityGroupKey).Order("-"+model.PrintJob_Created).Limit(limit),
+		&jobs)
+	return jobs, err
+}
+
+// UpdatePrintJob calls update to modify the job in a transaction.
+func (store *Store) UpdatePrintJob(ctx context.Context, id int64, update func(*model.PrintJob) error) error {
+	return store.updateEntity(ctx, printJobKey(id), func(job *model.PrintJob) error {
+		if job.Created.IsZero() {
+			return ErrNotFound
+		}
+		return update(job)
+	})
+}
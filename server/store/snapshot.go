@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+	"github.com/seaptc/server/model"
+)
+
+const registrationSnapshotKind = "registrationSnapshot"
+
+// SetRegistrationSnapshot stores the snapshot for the snapshot's date,
+// replacing an earlier snapshot for the same date.
+func (store *Store) SetRegistrationSnapshot(ctx context.Context, s *model.RegistrationSnapshot) error {
+	_, err := store.dsClient.Put(ctx, datastore.NameKey(registrationSnapshotKind, s.Date, conferenceEntityGroupKey), s)
+	return err
+}
+
+// GetRegistrationSnapshots returns all snapshots in order by date.
+func (store *Store) GetRegistrationSnapshots(ctx context.Context) ([]*model.RegistrationSnapshot, error) {
+	var snapshots []*model.RegistrationSnapshot
+	_, err := store.dsClient.GetAll(ctx,
+		datastore.NewQuery(registrationSnapshotKind).Ancestor(conferenceEntityGroupKey).Order("__key__"),
+		&snapshots)
+	return snapshots, err
+}
@@ -30,6 +30,7 @@ type Store struct {
 	dsClient        *datastore.Client
 	classInfoCache  valueCache
 	conferenceCache valueCache
+	webhookCache    valueCache
 }
 
 // NewFromFlags creates a client using flags defined in this package.
@@ -64,6 +64,12 @@ func main() {
 		if err := s.UpdateParticipants(ctx); err != nil {
 			log.Fatal(err)
 		}
+	case "migrate-participants":
+		n, err := s.MigrateParticipants(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d participants updated\n", n)
 	case "participant-get":
 		p, err := s.GetParticipant(ctx, flag.Arg(1))
 		if err != nil {
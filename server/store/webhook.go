@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/seaptc/server/model"
+)
+
+const (
+	webhookKind         = "webhook"
+	webhookDeliveryKind = "webhookDelivery"
+)
+
+func (store *Store) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var hooks []*model.Webhook
+	_, err := store.dsClient.GetAll(ctx, datastore.NewQuery(webhookKind).Ancestor(conferenceEntityGroupKey), &hooks)
+	return hooks, err
+}
+
+func (store *Store) GetCachedWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	v, err := store.webhookCache.get(ctx, 10*time.Minute, func() (interface{}, error) {
+		v, err := store.GetWebhooks(ctx)
+		return v, err
+	})
+	hooks, _ := v.([]*model.Webhook)
+	return hooks, err
+}
+
+func (store *Store) AddWebhook(ctx context.Context, hook *model.Webhook) error {
+	key, err := store.dsClient.Put(ctx, datastore.IncompleteKey(webhookKind, conferenceEntityGroupKey), hook)
+	store.webhookCache.clear()
+	if err != nil {
+		return err
+	}
+	hook.ID = key.ID
+	return nil
+}
+
+func (store *Store) DeleteWebhook(ctx context.Context, id int64) error {
+	err := store.dsClient.Delete(ctx, datastore.IDKey(webhookKind, id, conferenceEntityGroupKey))
+	store.webhookCache.clear()
+	return err
+}
+
+// AddWebhookDeliveries adds the deliveries and sets their IDs.
+func (store *Store) AddWebhookDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	keys := make([]*datastore.Key, len(deliveries))
+	for i := range deliveries {
+		keys[i] = datastore.IncompleteKey(webhookDeliveryKind, conferenceEntityGroupKey)
+	}
+	keys, err := store.dsClient.PutMulti(ctx, keys, deliveries)
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		deliveries[i].ID = key.ID
+	}
+	return nil
+}
+
+func (store *Store) UpdateWebhookDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	_, err := store.dsClient.Put(ctx, datastore.IDKey(webhookDeliveryKind, d.ID, conferenceEntityGroupKey), d)
+	return err
+}
+
+// GetPendingWebhookDeliveries returns the deliveries waiting for an attempt.
+func (store *Store) GetPendingWebhookDeliveries(ctx context.Context) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	_, err := store.dsClient.GetAll(ctx,
+		datastore.NewQuery(webhookDeliveryKind).Ancestor(conferenceEntityGroupKey).Filter(model.WebhookDelivery_Pending+"=", true),
+		&deliveries)
+	return deliveries, err
+}
+
+// GetWebhookDeliveries returns the most recent deliveries.
+func (store *Store) GetWebhookDeliveries(ctx context.Context, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	_, err := store.dsClient.GetAll(ctx,
+		datastore.NewQuery(webhookDeliveryKind).Ancestor(conferenceEntityGroupKey).Order("-"+model.WebhookDelivery_Time).Limit(limit),
+		&deliveries)
+	return deliveries, err
+}
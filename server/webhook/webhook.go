@@ -0,0 +1,129 @@
+// Package webhook delivers signed event notifications to subscriber URLs.
+//
+// Each delivery is a JSON encoded Event in the body of a POST request. The
+// request includes the following headers:
+//
+//	X-PTC-Event      The event type.
+//	X-PTC-Delivery   The event ID. Retries of the same event use the same ID.
+//	X-PTC-Signature  "sha256=" followed by the hex encoded HMAC-SHA256 of the
+//	                 request body using the webhook secret as the key.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	EventHeader     = "X-PTC-Event"
+	DeliveryHeader  = "X-PTC-Delivery"
+	SignatureHeader = "X-PTC-Signature"
+)
+
+// Event is the payload of a delivery.
+type Event struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// NewEvent returns an event with a random ID and the current time.
+func NewEvent(eventType string, data interface{}) (*Event, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:   hex.EncodeToString(b[:]),
+		Type: eventType,
+		Time: time.Now().UTC().Truncate(time.Second),
+		Data: data,
+	}, nil
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// Verify returns true if signature is a valid signature of body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Deliverer posts events to subscribers.
+type Deliverer struct {
+	Client *http.Client
+
+	// MaxAttempts is the maximum number of attempts per delivery.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry. The delay doubles after
+	// each retry.
+	Backoff time.Duration
+}
+
+// DefaultDeliverer is the Deliverer used by the application. Failed
+// deliveries are retried by the application's cron handler, so the backoff is
+// in minutes.
+var DefaultDeliverer = &Deliverer{
+	Client:      &http.Client{Timeout: 20 * time.Second},
+	MaxAttempts: 5,
+	Backoff:     5 * time.Minute,
+}
+
+// RetryDelay returns the delay before the next attempt of a delivery that
+// failed with status after the given number of attempts. RetryDelay returns
+// false if the delivery should not be retried. Requests that fail with a
+// client error other than 429 (Too Many Requests) are not retried.
+func (d *Deliverer) RetryDelay(attempts int, status int) (time.Duration, bool) {
+	if attempts >= d.MaxAttempts ||
+		(status >= 400 && status < 500 && status != http.StatusTooManyRequests) {
+		return 0, false
+	}
+	backoff := d.Backoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+	}
+	return backoff, true
+}
+
+// Post makes one attempt to post the JSON encoded event in body to url. Post
+// returns the HTTP status of the response, zero if the server did not
+// respond.
+func (d *Deliverer) Post(ctx context.Context, url string, secret string, e *Event, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "seaptc-webhook")
+	req.Header.Set(EventHeader, e.Type)
+	req.Header.Set(DeliveryHeader, e.ID)
+	req.Header.Set(SignatureHeader, Sign(secret, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook: %s returned %s", url, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
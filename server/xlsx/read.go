@@ -0,0 +1,225 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// IsXLSX returns true if the file name has the .xlsx extension.
+func IsXLSX(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".xlsx")
+}
+
+// ReadAll reads the rows of the first worksheet in the workbook. Cells are
+// returned as the stored text. Numbers are formatted without exponents and
+// dates are returned as serial numbers. Trailing empty cells are omitted.
+func ReadAll(r io.Reader) ([][]string, error) {
+	p, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(p), int64(len(p)))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sst sharedStrings
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if err := decodeFile(f, &sst); err != nil {
+			return nil, err
+		}
+	}
+
+	f := files[sheetPath]
+	if f == nil {
+		return nil, fmt.Errorf("xlsx: worksheet %s not found", sheetPath)
+	}
+	var ws worksheet
+	if err := decodeFile(f, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, xr := range ws.Rows {
+		i := len(rows)
+		if xr.R > 0 {
+			i = xr.R - 1
+		}
+		for len(rows) <= i {
+			rows = append(rows, nil)
+		}
+		var row []string
+		for k, c := range xr.Cells {
+			j := k
+			if c.R != "" {
+				j, err = cellColumn(c.R)
+				if err != nil {
+					return nil, err
+				}
+			}
+			var s string
+			switch c.T {
+			case "s":
+				n, err := strconv.Atoi(c.V)
+				if err != nil || n < 0 || n >= len(sst.Items) {
+					return nil, fmt.Errorf("xlsx: invalid shared string index in cell %s", c.R)
+				}
+				s = sst.Items[n].text()
+			case "inlineStr":
+				s = c.IS.text()
+			case "b":
+				if c.V == "1" {
+					s = "TRUE"
+				} else {
+					s = "FALSE"
+				}
+			case "n", "":
+				s = formatNumber(c.V)
+			default:
+				s = c.V
+			}
+			for len(row) <= j {
+				row = append(row, "")
+			}
+			row[j] = s
+		}
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func formatNumber(s string) string {
+	if s == "" {
+		return s
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cellColumn returns the zero based column index for a cell reference like
+// "AB12".
+func cellColumn(ref string) (int, error) {
+	n := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		b := ref[i]
+		if b < 'A' || b > 'Z' {
+			break
+		}
+		n = n*26 + int(b-'A') + 1
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("xlsx: invalid cell reference %q", ref)
+	}
+	return n - 1, nil
+}
+
+func decodeFile(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %v", f.Name, err)
+	}
+	return nil
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	f := files["xl/workbook.xml"]
+	if f == nil {
+		return "", errors.New("xlsx: workbook not found")
+	}
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(f, &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("xlsx: workbook does not have a sheet")
+	}
+
+	f = files["xl/_rels/workbook.xml.rels"]
+	if f == nil {
+		return "", errors.New("xlsx: workbook relationships not found")
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(f, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[0].ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", errors.New("xlsx: first sheet not found")
+}
+
+type richText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt *richText) text() string {
+	if len(rt.R) == 0 {
+		return rt.T
+	}
+	var buf strings.Builder
+	for _, r := range rt.R {
+		buf.WriteString(r.T)
+	}
+	return buf.String()
+}
+
+type sharedStrings struct {
+	Items []richText `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string   `xml:"r,attr"`
+			T  string   `xml:"t,attr"`
+			V  string   `xml:"v"`
+			IS richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
@@ -0,0 +1,341 @@
+// Package xlsx reads and writes simple Office Open XML (.xlsx) spreadsheets.
+//
+// The package supports a single worksheet with string, number, boolean and
+// date cells. Formulas, merged cells and most formatting are not supported.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ContentType is the MIME type for .xlsx files.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// CellType specifies how values in a column are stored.
+type CellType int
+
+const (
+	String CellType = iota
+	Number
+	Bool
+	Date
+	DateTime
+)
+
+// Column describes a worksheet column.
+type Column struct {
+	Name string
+	Type CellType
+
+	// Width in characters. A width is computed from the cell values when
+	// zero.
+	Width float64
+}
+
+// Writer accumulates rows for a worksheet. The first row of the worksheet is
+// a frozen header with the column names.
+type Writer struct {
+	sheetName string
+	columns   []Column
+	rows      [][]interface{}
+}
+
+// NewWriter returns a writer for a worksheet with the given name and columns.
+func NewWriter(sheetName string, columns []Column) *Writer {
+	return &Writer{sheetName: sheetName, columns: columns}
+}
+
+// Write adds a row to the worksheet. Values are converted to the type of the
+// column. Supported value types are string, bool, int, int64, float64 and
+// time.Time. Nil values and zero times are written as blank cells.
+func (w *Writer) Write(values ...interface{}) error {
+	if len(values) > len(w.columns) {
+		return fmt.Errorf("xlsx: row has %d values, expected at most %d", len(values), len(w.columns))
+	}
+	w.rows = append(w.rows, values)
+	return nil
+}
+
+// WriteTo writes the workbook to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	cw := &countWriter{w: out}
+	zw := zip.NewWriter(cw)
+	for _, f := range []struct {
+		name string
+		fn   func(*bufio.Writer) error
+	}{
+		{"[Content_Types].xml", writeString(contentTypesXML)},
+		{"_rels/.rels", writeString(relsXML)},
+		{"xl/workbook.xml", w.writeWorkbook},
+		{"xl/_rels/workbook.xml.rels", writeString(workbookRelsXML)},
+		{"xl/styles.xml", writeString(stylesXML)},
+		{"xl/worksheets/sheet1.xml", w.writeSheet},
+	} {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return cw.n, err
+		}
+		bw := bufio.NewWriter(fw)
+		if err := f.fn(bw); err != nil {
+			return cw.n, err
+		}
+		if err := bw.Flush(); err != nil {
+			return cw.n, err
+		}
+	}
+	err := zw.Close()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func writeString(s string) func(*bufio.Writer) error {
+	return func(w *bufio.Writer) error {
+		_, err := w.WriteString(s)
+		return err
+	}
+}
+
+func escape(w *bufio.Writer, s string) {
+	xml.EscapeText(w, []byte(s))
+}
+
+func (w *Writer) writeWorkbook(bw *bufio.Writer) error {
+	name := w.sheetName
+	if name == "" {
+		name = "Sheet1"
+	}
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`)
+	escape(bw, name)
+	bw.WriteString(`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+	return nil
+}
+
+// Style indices in stylesXML.
+const (
+	styleHeader   = 1
+	styleDate     = 2
+	styleDateTime = 3
+)
+
+// ColumnName returns the spreadsheet name of the column with zero based index
+// i: A, B, ... Z, AA, AB, ...
+func ColumnName(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+// excelEpoch is day zero for date serial numbers.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func dateSerial(t time.Time) float64 {
+	// Use wall clock time in the value's location.
+	y, m, d := t.Date()
+	u := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return u.Sub(excelEpoch).Hours() / 24
+}
+
+func (w *Writer) widths() []float64 {
+	widths := make([]float64, len(w.columns))
+	for j, c := range w.columns {
+		if c.Width > 0 {
+			widths[j] = c.Width
+			continue
+		}
+		n := len(c.Name)
+		for _, row := range w.rows {
+			if j >= len(row) {
+				continue
+			}
+			var m int
+			switch v := row[j].(type) {
+			case string:
+				m = len(v)
+			case time.Time:
+				m = 16
+			default:
+				m = len(fmt.Sprint(v))
+			}
+			if m > n {
+				n = m
+			}
+		}
+		widths[j] = math.Min(float64(n)+2, 60)
+	}
+	return widths
+}
+
+func (w *Writer) writeSheet(bw *bufio.Writer) error {
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	// Freeze the header row.
+	bw.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	bw.WriteString(`<cols>`)
+	for j, width := range w.widths() {
+		fmt.Fprintf(bw, `<col min="%d" max="%d" width="%.1f" customWidth="1"/>`, j+1, j+1, width)
+	}
+	bw.WriteString(`</cols><sheetData>`)
+
+	bw.WriteString(`<row r="1">`)
+	for j, c := range w.columns {
+		writeStringCell(bw, j, 1, c.Name, styleHeader)
+	}
+	bw.WriteString(`</row>`)
+
+	for i, row := range w.rows {
+		r := i + 2
+		fmt.Fprintf(bw, `<row r="%d">`, r)
+		for j, v := range row {
+			if err := w.writeCell(bw, j, r, v); err != nil {
+				return err
+			}
+		}
+		bw.WriteString(`</row>`)
+	}
+
+	bw.WriteString(`</sheetData>`)
+	if len(w.columns) > 0 {
+		fmt.Fprintf(bw, `<autoFilter ref="A1:%s%d"/>`, ColumnName(len(w.columns)-1), len(w.rows)+1)
+	}
+	bw.WriteString(`</worksheet>`)
+	return nil
+}
+
+func writeStringCell(bw *bufio.Writer, j, r int, s string, style int) {
+	fmt.Fprintf(bw, `<c r="%s%d" t="inlineStr"`, ColumnName(j), r)
+	if style != 0 {
+		fmt.Fprintf(bw, ` s="%d"`, style)
+	}
+	bw.WriteString(`><is><t xml:space="preserve">`)
+	escape(bw, s)
+	bw.WriteString(`</t></is></c>`)
+}
+
+func (w *Writer) writeCell(bw *bufio.Writer, j, r int, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	ref := ColumnName(j) + strconv.Itoa(r)
+	switch w.columns[j].Type {
+	case String:
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case time.Time:
+			if v.IsZero() {
+				return nil
+			}
+			s = v.Format(time.RFC3339)
+		default:
+			s = fmt.Sprint(v)
+		}
+		if s != "" {
+			writeStringCell(bw, j, r, s, 0)
+		}
+	case Number:
+		var f float64
+		switch v := v.(type) {
+		case int:
+			f = float64(v)
+		case int64:
+			f = float64(v)
+		case float64:
+			f = v
+		case string:
+			if v == "" {
+				return nil
+			}
+			var err error
+			f, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				// Not a number, write as string.
+				writeStringCell(bw, j, r, v, 0)
+				return nil
+			}
+		default:
+			return fmt.Errorf("xlsx: cannot write %T to number column %q", v, w.columns[j].Name)
+		}
+		fmt.Fprintf(bw, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(f, 'g', -1, 64))
+	case Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("xlsx: cannot write %T to boolean column %q", v, w.columns[j].Name)
+		}
+		n := 0
+		if b {
+			n = 1
+		}
+		fmt.Fprintf(bw, `<c r="%s" t="b"><v>%d</v></c>`, ref, n)
+	case Date, DateTime:
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("xlsx: cannot write %T to date column %q", v, w.columns[j].Name)
+		}
+		if t.IsZero() {
+			return nil
+		}
+		style := styleDate
+		if w.columns[j].Type == DateTime {
+			style = styleDateTime
+		}
+		fmt.Fprintf(bw, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(dateSerial(t), 'f', -1, 64))
+	}
+	return nil
+}
+
+const contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const relsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// stylesXML defines the cell formats: 0 default, 1 bold header, 2 date and 3
+// date time.
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`